@@ -2,74 +2,83 @@ package api
 
 import (
 	"context"
-	"encoding/base64"
-	"errors"
+	"golang.org/x/oauth2"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/gmail/v1"
-	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
+// Service calls the Sheets, Drive and Gmail APIs. The client of each API is created on its first use.
 type Service struct {
-	sheets *sheets.Service
-	drive  *drive.Service
-	gmail  *gmail.Service
+	// first, so that its counters are aligned for atomic operations
+	stats       Stats
+	logger      *Logger
+	ctx         context.Context
+	tokenSource oauth2.TokenSource
+	scopes      []string
+
+	sheetsOnce sync.Once
+	sheets     *sheets.Service
+	driveOnce  sync.Once
+	drive      *drive.Service
+	gmailOnce  sync.Once
+	gmail      *gmail.Service
 }
 
+// NewService authenticates with the credentials JSON and creates the service
 func NewService(ctx context.Context, credentialsJson []byte) (*Service, error) {
-	sheetsService, err := sheets.NewService(ctx, option.WithCredentialsJSON(credentialsJson))
-	if err != nil {
-		return nil, err
-	}
+	return NewServiceWithCredentials(ctx, &Credentials{
+		JSON: credentialsJson,
+	})
+}
 
-	driveService, err := drive.NewService(ctx, option.WithCredentialsJSON(credentialsJson))
-	if err != nil {
-		return nil, err
-	}
+// SetLogger logs every request of the service at debug level, and retries at warn level
+func (s *Service) SetLogger(logger *Logger) {
+	s.logger = logger
+}
 
-	gmailService, err := gmail.NewService(ctx, option.WithCredentialsJSON(credentialsJson))
-	if err != nil {
-		return nil, err
+// Stats returns the counts of the requests of the service so far
+func (s *Service) Stats() Stats {
+	return Stats{
+		Requests:  atomic.LoadInt64(&s.stats.Requests),
+		BytesSent: atomic.LoadInt64(&s.stats.BytesSent),
+		Retries:   atomic.LoadInt64(&s.stats.Retries),
 	}
-
-	return &Service{
-		sheets: sheetsService,
-		drive:  driveService,
-		gmail:  gmailService,
-	}, nil
 }
 
+// Share grants writer to the user with the email, notifying them with Drive's default email
 func (s *Service) Share(fileId string, email string) error {
-	permission := &drive.Permission{
+	return s.SharePermission(fileId, &Permission{
 		EmailAddress: email,
 		Role:         "writer",
 		Type:         "user",
-	}
-	_, err := s.drive.Permissions.Create(fileId, permission).Do()
-	return err
+	}, &ShareOptions{})
 }
 
 func (s *Service) Create(title string) (string, error) {
-	spreadsheet, err := s.sheets.Spreadsheets.Create(&sheets.Spreadsheet{
+	spreadsheet, err := s.sheetsService().Spreadsheets.Create(&sheets.Spreadsheet{
 		Properties: &sheets.SpreadsheetProperties{
 			Title: title,
 		},
 	}).Do()
 	if err != nil {
-		return "", err
+		return "", s.sheetsError(err, "", "")
 	}
 	return spreadsheet.SpreadsheetId, nil
 }
 
 func (s *Service) Recreate(spreadsheetId string, title string) error {
-	resp, err := s.sheets.Spreadsheets.Get(spreadsheetId).IncludeGridData(true).Do()
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).IncludeGridData(true).Do()
 	if err != nil {
-		return err
+		return s.sheetsError(err, spreadsheetId, "")
 	}
 
 	if len(resp.Sheets) == 0 {
-		return errors.New("0 sheets")
+		return noSheetsError(spreadsheetId)
 	}
 
 	var requests []*sheets.Request
@@ -100,7 +109,9 @@ func (s *Service) Recreate(spreadsheetId string, title string) error {
 				{
 					AddSheet: &sheets.AddSheetRequest{
 						Properties: &sheets.SheetProperties{
-							Title: "Sheet1",
+							Title:           "Sheet1",
+							Index:           0,
+							ForceSendFields: []string{"Index"},
 						},
 					},
 				},
@@ -119,24 +130,82 @@ func (s *Service) Recreate(spreadsheetId string, title string) error {
 		requests = append(requests, &sheets.Request{
 			AddSheet: &sheets.AddSheetRequest{
 				Properties: &sheets.SheetProperties{
-					Title: "Sheet1",
+					Title:           "Sheet1",
+					Index:           0,
+					ForceSendFields: []string{"Index"},
 				},
 			},
 		})
 	}
 
-	_, err = s.sheets.Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
 		Requests: requests,
 	}).Do()
-	return err
+	return s.sheetsError(err, spreadsheetId, "")
 }
 
 func (s *Service) InsertTable(spreadsheetId string, cellPosition *CellPosition, table [][]string) error {
+	return s.insertTable(spreadsheetId, "", cellPosition, table, false)
+}
+
+// InsertTableInSheet clears the sheet with the given title, adding the sheet if it is missing, and inserts the table.
+// The sheet is left as it was when the table does not fit.
+func (s *Service) InsertTableInSheet(spreadsheetId string, title string, cellPosition *CellPosition, table [][]string) error {
+	_, err := s.FindOrAddSheet(spreadsheetId, title)
+	if err != nil {
+		return err
+	}
+	return s.insertTable(spreadsheetId, title, cellPosition, table, true)
+}
+
+// insertTable checks the table against the limits of a spreadsheet, grows the sheet with the given title,
+// or the first sheet when title is empty, to fit the table at cellPosition, clears the sheet when clear is set,
+// and writes the table
+func (s *Service) insertTable(spreadsheetId string, title string, cellPosition *CellPosition, table [][]string, clear bool) error {
 	if len(table) == 0 || len(table[0]) == 0 {
-		return errors.New("Attempting to insert empty table")
+		return &Error{
+			Kind:          ErrEmptyTable,
+			SpreadsheetId: spreadsheetId,
+			Range:         title,
+		}
+	}
+	err := checkCellLengths(cellPosition, table)
+	if err != nil {
+		return err
+	}
+
+	sheetsProperties, err := s.getSheetsProperties(spreadsheetId)
+	if err != nil {
+		return err
 	}
+	properties, err := findSheetProperties(sheetsProperties, title)
+	if err != nil {
+		return err
+	}
+	err = s.ensureGridSize(spreadsheetId, sheetsProperties, properties,
+		int64(cellPosition.RowIndex+len(table)-1), int64(cellPosition.ColumnIndex+tableWidth(table)-1))
+	if err != nil {
+		return err
+	}
+
+	if clear {
+		_, err = s.sheetsService().Spreadsheets.Values.Clear(spreadsheetId, sheetRange(title), &sheets.ClearValuesRequest{}).Do()
+		if err != nil {
+			return s.sheetsError(err, spreadsheetId, title)
+		}
+	}
+
+	rangePrefix := ""
+	if title != "" {
+		rangePrefix = sheetRange(title) + "!"
+	}
+	return s.writeTable(spreadsheetId, rangePrefix, cellPosition, table)
+}
+
+// writeTable writes the table to the range starting at cellPosition, prefixed with rangePrefix to target a sheet
+func (s *Service) writeTable(spreadsheetId string, rangePrefix string, cellPosition *CellPosition, table [][]string) error {
 	height := len(table)
-	width := len(table[0])
+	width := tableWidth(table)
 
 	var tableRaw [][]interface{}
 	for _, row := range table {
@@ -156,19 +225,31 @@ func (s *Service) InsertTable(spreadsheetId string, cellPosition *CellPosition,
 		return err
 	}
 
-	_, err = s.sheets.Spreadsheets.Values.Update(spreadsheetId, start+":"+end, &sheets.ValueRange{
+	rangeName := rangePrefix + start + ":" + end
+	_, err = s.sheetsService().Spreadsheets.Values.Update(spreadsheetId, rangeName, &sheets.ValueRange{
 		Values: tableRaw,
 	}).ValueInputOption("USER_ENTERED").Do()
-	return err
+	return s.sheetsError(err, spreadsheetId, rangeName)
+}
+
+// tableWidth returns the length of the longest row
+func tableWidth(table [][]string) int {
+	width := 0
+	for _, row := range table {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	return width
 }
 
 func (s *Service) AddChart(spreadsheetId string, chart *Chart) error {
-	resp, err := s.sheets.Spreadsheets.Get(spreadsheetId).IncludeGridData(true).Do()
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).IncludeGridData(true).Do()
 	if err != nil {
-		return err
+		return s.sheetsError(err, spreadsheetId, "")
 	}
 	if len(resp.Sheets) == 0 {
-		return errors.New("0 sheets")
+		return noSheetsError(spreadsheetId)
 	}
 	sheetId := resp.Sheets[0].Properties.SheetId
 
@@ -201,6 +282,12 @@ func (s *Service) AddChart(spreadsheetId string, chart *Chart) error {
 			min = math.Min(min, *numberValue)
 		}
 	}
+
+	fontFamily := chart.FontFamily
+	if fontFamily == "" {
+		fontFamily = "Roboto"
+	}
+
 	var viewWindowOptions *sheets.ChartAxisViewWindowOptions
 	if hasData {
 		viewWindowOptions = &sheets.ChartAxisViewWindowOptions{
@@ -230,7 +317,7 @@ func (s *Service) AddChart(spreadsheetId string, chart *Chart) error {
 						Axis: []*sheets.BasicChartAxis{
 							{
 								Format: &sheets.TextFormat{
-									FontFamily: "Roboto",
+									FontFamily: fontFamily,
 								},
 								Position:          "BOTTOM_AXIS",
 								Title:             chart.XAxisTitle, // "tag",
@@ -238,7 +325,7 @@ func (s *Service) AddChart(spreadsheetId string, chart *Chart) error {
 							},
 							{
 								Format: &sheets.TextFormat{
-									FontFamily: "Roboto",
+									FontFamily: fontFamily,
 								},
 								Position:          "LEFT_AXIS",
 								Title:             chart.YAxisTitle,
@@ -268,7 +355,7 @@ func (s *Service) AddChart(spreadsheetId string, chart *Chart) error {
 							{
 								DataLabel: &sheets.DataLabel{
 									TextFormat: &sheets.TextFormat{
-										FontFamily: "Roboto",
+										FontFamily: fontFamily,
 									},
 									Type: "NONE",
 								},
@@ -289,48 +376,77 @@ func (s *Service) AddChart(spreadsheetId string, chart *Chart) error {
 							},
 						},
 					},
-					FontName:                "Roboto",
+					FontName:                fontFamily,
 					HiddenDimensionStrategy: "SKIP_HIDDEN_ROWS_AND_COLUMNS",
 					Title:                   chart.Title,
 					TitleTextFormat: &sheets.TextFormat{
-						FontFamily: "Roboto",
+						FontFamily: fontFamily,
 					},
 				},
 			},
 		},
 	}
 
-	_, err = s.sheets.Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
 		Requests: []*sheets.Request{
 			request,
 		},
 	}).Do()
 
-	return err
+	return s.sheetsError(err, spreadsheetId, "")
 }
 
 func (s *Service) GetFirstSheetId(spreadsheetId string) (int64, error) {
-	resp, err := s.sheets.Spreadsheets.Get(spreadsheetId).Do()
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).Do()
 	if err != nil {
-		return 0, err
+		return 0, s.sheetsError(err, spreadsheetId, "")
 	}
 	if len(resp.Sheets) == 0 {
-		return 0, errors.New("0 sheets")
+		return 0, noSheetsError(spreadsheetId)
 	}
 	return resp.Sheets[0].Properties.SheetId, nil
 }
 
+// FindOrAddSheet returns the id of the sheet with the given title, adding the sheet if it is missing
+func (s *Service) FindOrAddSheet(spreadsheetId string, title string) (int64, error) {
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).Do()
+	if err != nil {
+		return 0, s.sheetsError(err, spreadsheetId, "")
+	}
+	for _, sheet := range resp.Sheets {
+		if sheet.Properties.Title == title {
+			return sheet.Properties.SheetId, nil
+		}
+	}
+
+	batchResp, err := s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				AddSheet: &sheets.AddSheetRequest{
+					Properties: &sheets.SheetProperties{
+						Title: title,
+					},
+				},
+			},
+		},
+	}).Do()
+	if err != nil {
+		return 0, s.sheetsError(err, spreadsheetId, "")
+	}
+	return batchResp.Replies[0].AddSheet.Properties.SheetId, nil
+}
+
 func (s *Service) Highlight(spreadsheetId string,
 	startPosition *CellPosition, endPosition *CellPosition,
 	lowerRange *Boundary, upperRange *Boundary,
 	chosenColor *Color) error {
 
-	resp, err := s.sheets.Spreadsheets.Get(spreadsheetId).IncludeGridData(true).Do()
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).IncludeGridData(true).Do()
 	if err != nil {
-		return err
+		return s.sheetsError(err, spreadsheetId, "")
 	}
 	if len(resp.Sheets) == 0 {
-		return errors.New("0 sheets")
+		return noSheetsError(spreadsheetId)
 	}
 	sheetId := resp.Sheets[0].Properties.SheetId
 
@@ -381,13 +497,13 @@ func (s *Service) Highlight(spreadsheetId string,
 		},
 	}
 
-	_, err = s.sheets.Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
 		Requests: []*sheets.Request{
 			request,
 		},
 	}).Do()
 
-	return err
+	return s.sheetsError(err, spreadsheetId, "")
 }
 
 func (s *Service) GradientHighlight(spreadsheetId string,
@@ -396,15 +512,15 @@ func (s *Service) GradientHighlight(spreadsheetId string,
 	color1 *Color, color2 *Color) error {
 
 	if lowerRange == upperRange {
-		return errors.New("lower range equal upper range")
+		return validationErrorf("lower range equal upper range")
 	}
 
-	resp, err := s.sheets.Spreadsheets.Get(spreadsheetId).IncludeGridData(true).Do()
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).IncludeGridData(true).Do()
 	if err != nil {
-		return err
+		return s.sheetsError(err, spreadsheetId, "")
 	}
 	if len(resp.Sheets) == 0 {
-		return errors.New("0 sheets")
+		return noSheetsError(spreadsheetId)
 	}
 	sheetId := resp.Sheets[0].Properties.SheetId
 
@@ -455,35 +571,20 @@ func (s *Service) GradientHighlight(spreadsheetId string,
 		},
 	}
 
-	_, err = s.sheets.Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
 		Requests: []*sheets.Request{
 			request,
 		},
 	}).Do()
 
-	return err
+	return s.sheetsError(err, spreadsheetId, "")
 }
 
+// SendEmail sends the plain text message to the comma separated users
 func (s *Service) SendEmail(users string, title string, message string) error {
-	messageObject := &gmail.Message{
-		Payload: &gmail.MessagePart{
-			Body: &gmail.MessagePartBody{
-				Data: base64.StdEncoding.EncodeToString([]byte(message)),
-			},
-			Headers: []*gmail.MessagePartHeader{
-				{
-					Name:  "To",
-					Value: users,
-				},
-				{
-					Name:  "Subject",
-					Value: title,
-				},
-			},
-			MimeType: "text/html",
-		},
-	}
-
-	_, err := s.gmail.Users.Messages.Send("me", messageObject).Do()
-	return err
+	return s.Send(&Email{
+		To:      strings.Split(users, ","),
+		Subject: title,
+		Text:    message,
+	})
 }
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestInsertTableInSheetChecksBeforeClearing(t *testing.T) {
+	tests := []struct {
+		name  string
+		table [][]string
+	}{
+		{
+			name:  "too many cells",
+			table: append([][]string{{"a", "b"}}, make([][]string, 1000)...),
+		},
+		{
+			name:  "cell too long",
+			table: [][]string{{"a"}, {strings.Repeat("x", maxCellCharacters+1)}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests []string
+			s := testService(t, func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				if r.Method != http.MethodGet {
+					t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+					io.WriteString(w, `{}`)
+					return
+				}
+				// Other fills the spreadsheet up to the cell limit, so Changes cannot grow
+				io.WriteString(w, `{"sheets": [
+					{"properties": {"sheetId": 0, "title": "Changes", "gridProperties": {"rowCount": 1000, "columnCount": 1}}},
+					{"properties": {"sheetId": 1, "title": "Other", "gridProperties": {"rowCount": 9999, "columnCount": 1000}}}
+				]}`)
+			})
+
+			err := s.InsertTableInSheet("id", "Changes", Origin(), test.table)
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("got %v, want ErrValidation", err)
+			}
+			for _, request := range requests {
+				if strings.HasSuffix(request, ":clear") {
+					t.Errorf("sheet cleared before the table was checked: %q", requests)
+				}
+			}
+		})
+	}
+}
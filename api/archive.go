@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Archive keeps the previous report before the spreadsheet is recreated
+type Archive struct {
+	// tab duplicates the previous first sheet into a dated tab, copy copies the whole spreadsheet with Drive
+	Mode string `json:"mode"`
+	// Drive folder id of copies. Defaults to the folder of the spreadsheet
+	Folder string `json:"folder"`
+	// Number of archives kept, pruning the oldest. 0 keeps every archive
+	Keep int `json:"keep"`
+}
+
+// App property of archive copies holding the id of the archived spreadsheet
+const archiveProperty = "archiveOf"
+
+var archiveTabTitle = regexp.MustCompile(`^Sheet1 \((\d{4}-\d{2}-\d{2}.*)\)$`)
+
+// ArchiveTab duplicates Sheet1 into a tab titled with the date, e.g. Sheet1 (2026-10-15), and returns the title.
+// The time is added to the title when the spreadsheet already has an archive of the same date.
+// When the spreadsheet has no Sheet1, e.g. it was renamed, there is nothing to archive and the title is empty.
+func (s *Service) ArchiveTab(spreadsheetId string, date time.Time) (string, error) {
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).Fields("sheets.properties").Do()
+	if err != nil {
+		return "", s.sheetsError(err, spreadsheetId, "")
+	}
+
+	var sheetId int64 = -1
+	titles := make(map[string]bool)
+	for _, sheet := range resp.Sheets {
+		titles[sheet.Properties.Title] = true
+		if sheet.Properties.Title == "Sheet1" {
+			sheetId = sheet.Properties.SheetId
+		}
+	}
+	if sheetId < 0 {
+		return "", nil
+	}
+
+	title := "Sheet1 (" + date.Format("2006-01-02") + ")"
+	if titles[title] {
+		title = "Sheet1 (" + date.Format("2006-01-02 15:04:05") + ")"
+	}
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				DuplicateSheet: &sheets.DuplicateSheetRequest{
+					SourceSheetId:    sheetId,
+					InsertSheetIndex: int64(len(resp.Sheets)),
+					NewSheetName:     title,
+				},
+			},
+		},
+	}).Do()
+	if err != nil {
+		return "", s.sheetsError(err, spreadsheetId, "")
+	}
+	return title, nil
+}
+
+// PruneArchiveTabs deletes the oldest archive tabs so that at most keep are left
+func (s *Service) PruneArchiveTabs(spreadsheetId string, keep int) error {
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).Fields("sheets.properties").Do()
+	if err != nil {
+		return s.sheetsError(err, spreadsheetId, "")
+	}
+
+	var archives []*sheets.SheetProperties
+	for _, sheet := range resp.Sheets {
+		if archiveTabTitle.MatchString(sheet.Properties.Title) {
+			archives = append(archives, sheet.Properties)
+		}
+	}
+	if len(archives) <= keep {
+		return nil
+	}
+	// dates in the titles sort in time order
+	sort.Slice(archives, func(i, j int) bool {
+		return archiveTabTitle.FindStringSubmatch(archives[i].Title)[1] < archiveTabTitle.FindStringSubmatch(archives[j].Title)[1]
+	})
+
+	var requests []*sheets.Request
+	for _, archive := range archives[:len(archives)-keep] {
+		requests = append(requests, &sheets.Request{
+			DeleteSheet: &sheets.DeleteSheetRequest{
+				SheetId: archive.SheetId,
+			},
+		})
+	}
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+// ArchiveCopy copies the spreadsheet with Drive into the folder, or the folder of the spreadsheet when folderId is empty,
+// named with the date, and returns the id of the copy
+func (s *Service) ArchiveCopy(spreadsheetId string, folderId string, date time.Time) (string, error) {
+	file, err := s.driveService().Files.Get(spreadsheetId).Fields("name").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", s.driveError(err, spreadsheetId)
+	}
+
+	archive := &drive.File{
+		Name: file.Name + " (" + date.Format("2006-01-02 15:04:05") + ")",
+		AppProperties: map[string]string{
+			archiveProperty: spreadsheetId,
+		},
+	}
+	if folderId != "" {
+		archive.Parents = []string{folderId}
+	}
+	archive, err = s.driveService().Files.Copy(spreadsheetId, archive).Fields("id").SupportsAllDrives(true).Do()
+	if err != nil {
+		// the spreadsheet was just found, so a missing file is the folder
+		return "", s.driveError(err, folderId)
+	}
+	return archive.Id, nil
+}
+
+// PruneArchiveCopies moves the oldest copies made by ArchiveCopy to the trash so that at most keep are left
+func (s *Service) PruneArchiveCopies(spreadsheetId string, keep int) error {
+	query := fmt.Sprintf("appProperties has { key='%s' and value='%s' } and trashed = false", archiveProperty, spreadsheetId)
+	var archives []*drive.File
+	pageToken := ""
+	for {
+		list, err := s.driveService().Files.List().Q(query).OrderBy("createdTime desc").Fields("nextPageToken", "files(id)").
+			SupportsAllDrives(true).IncludeItemsFromAllDrives(true).PageToken(pageToken).Do()
+		if err != nil {
+			return s.driveError(err, "")
+		}
+		archives = append(archives, list.Files...)
+		pageToken = list.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	if len(archives) <= keep {
+		return nil
+	}
+
+	for _, archive := range archives[keep:] {
+		_, err := s.driveService().Files.Update(archive.Id, &drive.File{Trashed: true}).SupportsAllDrives(true).Do()
+		if err != nil {
+			return s.driveError(err, archive.Id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+	"net"
+	"net/http"
+	"os"
+)
+
+// Credentials select how the service authenticates. The credentials JSON is taken from JSON, File or Env, in that order,
+// and Application Default Credentials are used when none is set.
+type Credentials struct {
+	// Service account key, authorized user or OAuth client JSON
+	JSON []byte
+	// File holding the credentials JSON
+	File string
+	// Environment variable holding the credentials JSON
+	Env string
+	// Token cache of the OAuth installed app flow, used when the credentials are an OAuth client.
+	// Without a cached token, the flow asks the user to sign in with a browser.
+	TokenFile string
+	// User impersonated by a service account with domain-wide delegation
+	Subject string
+	// Service account impersonated with the IAM credentials API, which the credentials need the token creator role of
+	ImpersonateServiceAccount string
+	// OAuth scopes requested, e.g. SheetsScope and DriveFileScope. Defaults to the scopes of every operation of Service
+	Scopes []string
+}
+
+// Scopes of every operation of Service
+var serviceScopes = []string{
+	SheetsScope,
+	DriveScope,
+	GmailSendScope,
+}
+
+// NewServiceWithCredentials authenticates with the credentials and creates the service
+func NewServiceWithCredentials(ctx context.Context, credentials *Credentials) (*Service, error) {
+	scopes := credentials.Scopes
+	if len(scopes) == 0 {
+		scopes = serviceScopes
+	}
+	tokenSource, err := credentials.tokenSource(ctx, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		ctx:         ctx,
+		tokenSource: tokenSource,
+		scopes:      scopes,
+	}, nil
+}
+
+func (c *Credentials) tokenSource(ctx context.Context, scopes []string) (oauth2.TokenSource, error) {
+	credentialsJson, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// the impersonating credentials call the IAM credentials API, and the impersonated account gets the scopes
+	baseScopes := scopes
+	if c.ImpersonateServiceAccount != "" {
+		baseScopes = []string{"https://www.googleapis.com/auth/cloud-platform"}
+	}
+
+	var tokenSource oauth2.TokenSource
+	if len(credentialsJson) == 0 {
+		params := google.CredentialsParams{Scopes: baseScopes}
+		if c.ImpersonateServiceAccount == "" {
+			params.Subject = c.Subject
+		}
+		defaultCredentials, err := google.FindDefaultCredentialsWithParams(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		tokenSource = defaultCredentials.TokenSource
+	} else if isOAuthClient(credentialsJson) {
+		tokenSource, err = installedAppTokenSource(ctx, credentialsJson, c.TokenFile, baseScopes)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		params := google.CredentialsParams{Scopes: baseScopes}
+		if c.ImpersonateServiceAccount == "" {
+			params.Subject = c.Subject
+		}
+		jsonCredentials, err := google.CredentialsFromJSONWithParams(ctx, credentialsJson, params)
+		if err != nil {
+			return nil, err
+		}
+		tokenSource = jsonCredentials.TokenSource
+	}
+
+	if c.ImpersonateServiceAccount == "" {
+		return tokenSource, nil
+	}
+	return impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: c.ImpersonateServiceAccount,
+		Scopes:          scopes,
+		Subject:         c.Subject,
+	}, option.WithTokenSource(tokenSource))
+}
+
+// load returns the credentials JSON, or nothing for Application Default Credentials
+func (c *Credentials) load() ([]byte, error) {
+	if len(c.JSON) > 0 {
+		return c.JSON, nil
+	}
+	if c.File != "" {
+		return os.ReadFile(c.File)
+	}
+	if c.Env != "" {
+		value := os.Getenv(c.Env)
+		if value == "" {
+			return nil, fmt.Errorf("environment variable %s is empty", c.Env)
+		}
+		return []byte(value), nil
+	}
+	return nil, nil
+}
+
+// isOAuthClient reports whether the JSON is an OAuth client downloaded from the Cloud console
+func isOAuthClient(credentialsJson []byte) bool {
+	var client struct {
+		Installed json.RawMessage `json:"installed"`
+		Web       json.RawMessage `json:"web"`
+	}
+	return json.Unmarshal(credentialsJson, &client) == nil && (client.Installed != nil || client.Web != nil)
+}
+
+// installedAppTokenSource returns the token cached in tokenFile, or signs the user in with a browser and caches the token
+func installedAppTokenSource(ctx context.Context, clientJson []byte, tokenFile string, scopes []string) (oauth2.TokenSource, error) {
+	config, err := google.ConfigFromJSON(clientJson, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	if tokenFile == "" {
+		return nil, errors.New("OAuth client credentials need a token file")
+	}
+
+	token := &oauth2.Token{}
+	b, err := os.ReadFile(tokenFile)
+	if err == nil {
+		err = json.Unmarshal(b, token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token file %s: %w", tokenFile, err)
+		}
+		return config.TokenSource(ctx, token), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	token, err = signIn(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	b, err = json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(tokenFile, b, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return config.TokenSource(ctx, token), nil
+}
+
+// signIn asks the user to open the consent page, and receives the authorization code on a loopback redirect
+func signIn(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	loopbackConfig := *config
+	loopbackConfig.RedirectURL = "http://" + listener.Addr().String()
+	stateBytes := make([]byte, 16)
+	_, err = rand.Read(stateBytes)
+	if err != nil {
+		return nil, err
+	}
+	state := hex.EncodeToString(stateBytes)
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if query.Get("state") != state {
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				return
+			}
+			if query.Get("error") != "" {
+				errs <- fmt.Errorf("sign in failed: %s", query.Get("error"))
+				http.Error(w, "Sign in failed, you can close this tab", http.StatusBadRequest)
+				return
+			}
+			codes <- query.Get("code")
+			fmt.Fprintln(w, "Signed in, you can close this tab")
+		}),
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintln(os.Stderr, "Open this link to sign in:\n"+loopbackConfig.AuthCodeURL(state, oauth2.AccessTypeOffline))
+	select {
+	case code := <-codes:
+		return loopbackConfig.Exchange(ctx, code)
+	case err := <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import "google.golang.org/api/sheets/v4"
+
+// Banding colors alternating rows of a range, with a separate color for its header row
+type Banding struct {
+	HeaderColor     *Color `json:"header_color"`
+	FirstBandColor  *Color `json:"first_band_color"`
+	SecondBandColor *Color `json:"second_band_color"`
+}
+
+// DefaultBanding is a grey header over white and light grey rows
+func DefaultBanding() *Banding {
+	return &Banding{
+		HeaderColor:     &Color{R: 0.8, G: 0.8, B: 0.8, A: 1},
+		FirstBandColor:  &Color{R: 1, G: 1, B: 1, A: 1},
+		SecondBandColor: &Color{R: 0.95, G: 0.95, B: 0.95, A: 1},
+	}
+}
+
+func (s *Service) AddBanding(spreadsheetId string, startPosition *CellPosition, endPosition *CellPosition, banding *Banding) error {
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	properties := &sheets.BandingProperties{}
+	if banding.HeaderColor != nil {
+		properties.HeaderColor = banding.HeaderColor.toSheetsColor()
+	}
+	if banding.FirstBandColor != nil {
+		properties.FirstBandColor = banding.FirstBandColor.toSheetsColor()
+	}
+	if banding.SecondBandColor != nil {
+		properties.SecondBandColor = banding.SecondBandColor.toSheetsColor()
+	}
+
+	request := &sheets.Request{
+		AddBanding: &sheets.AddBandingRequest{
+			BandedRange: &sheets.BandedRange{
+				Range:         toGridRange(sheetId, startPosition, endPosition),
+				RowProperties: properties,
+			},
+		},
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			request,
+		},
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"fmt"
+	"google.golang.org/api/sheets/v4"
 	"math"
 	"strconv"
+	"strings"
 	"unicode"
 )
 
@@ -48,6 +50,32 @@ func FromAlphaNumric(alphaNumeric string) (*CellPosition, error) {
 	}, nil
 }
 
+// ParseRange converts a range in A1 notation, e.g. A1:D10, to its start and end positions.
+// A single cell, e.g. B2, is both the start and the end of its range. Malformed ranges are ErrInvalidRange.
+func ParseRange(a1Range string) (*CellPosition, *CellPosition, error) {
+	start, end, isRange := strings.Cut(a1Range, ":")
+	startPosition, err := FromAlphaNumric(start)
+	if err != nil {
+		return nil, nil, &Error{
+			Kind:  ErrInvalidRange,
+			Range: a1Range,
+			Err:   err,
+		}
+	}
+	if !isRange {
+		return startPosition, startPosition, nil
+	}
+	endPosition, err := FromAlphaNumric(end)
+	if err != nil {
+		return nil, nil, &Error{
+			Kind:  ErrInvalidRange,
+			Range: a1Range,
+			Err:   err,
+		}
+	}
+	return startPosition, endPosition, nil
+}
+
 func (c *CellPosition) Offset(rowOffset int, columnOffset int) *CellPosition {
 	return &CellPosition{
 		RowIndex:    c.RowIndex + rowOffset,
@@ -55,6 +83,17 @@ func (c *CellPosition) Offset(rowOffset int, columnOffset int) *CellPosition {
 	}
 }
 
+// toGridRange converts the inclusive, 1-indexed cell positions to a 0-indexed, half-open grid range
+func toGridRange(sheetId int64, startPosition *CellPosition, endPosition *CellPosition) *sheets.GridRange {
+	return &sheets.GridRange{
+		EndColumnIndex:   int64(endPosition.ColumnIndex),
+		EndRowIndex:      int64(endPosition.RowIndex),
+		SheetId:          sheetId,
+		StartColumnIndex: int64(startPosition.ColumnIndex - 1),
+		StartRowIndex:    int64(startPosition.RowIndex - 1),
+	}
+}
+
 // indexToColumn takes in an index value & converts it to A1 Notation
 // Index 1 is Column A
 // E.g. 3 == C, 29 == AC, 731 == ABC
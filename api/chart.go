@@ -14,6 +14,8 @@ type Chart struct {
 	YAxisTitle  string `json:"y_axis_title"`
 	LabelColumn string `json:"label_column"`
 	DataColumn  string `json:"data_column"`
+	// Defaults to Roboto
+	FontFamily string `json:"font_family"`
 }
 
 // 0-indexed coordinate
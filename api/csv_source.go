@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type CsvOptions struct {
+	// Defaults to ,
+	Delimiter rune
+	// Lines starting with Comment are skipped. Zero disables comments
+	Comment    rune
+	LazyQuotes bool
+	// The first row is data, and the columns are named A, B, C...
+	NoHeader bool
+}
+
+type csvSource struct {
+	file   io.ReadCloser
+	reader *csv.Reader
+	// first data row, held back while the generated header is returned
+	pending []string
+	started bool
+	options *CsvOptions
+}
+
+// NewCsvSource reads delimiter separated values from file, which is closed with the source
+func NewCsvSource(file io.ReadCloser, options *CsvOptions) TableSource {
+	reader := csv.NewReader(file)
+	if options.Delimiter != 0 {
+		reader.Comma = options.Delimiter
+	}
+	reader.Comment = options.Comment
+	reader.LazyQuotes = options.LazyQuotes
+
+	return &csvSource{
+		file:    file,
+		reader:  reader,
+		options: options,
+	}
+}
+
+func (c *csvSource) Next() ([]string, error) {
+	if !c.started {
+		c.started = true
+		if c.options.NoHeader {
+			row, err := c.reader.Read()
+			if err != nil {
+				return nil, err
+			}
+			c.pending = row
+			return columnHeader(len(row)), nil
+		}
+	}
+	if c.pending != nil {
+		row := c.pending
+		c.pending = nil
+		return row, nil
+	}
+	return c.reader.Read()
+}
+
+func (c *csvSource) Close() error {
+	return c.file.Close()
+}
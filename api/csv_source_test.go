@@ -0,0 +1,65 @@
+package api
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCsvSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		options *CsvOptions
+		want    [][]string
+	}{
+		{
+			name:    "default delimiter",
+			input:   "name,score\nalpha,\"1,5\"\n",
+			options: &CsvOptions{},
+			want:    [][]string{{"name", "score"}, {"alpha", "1,5"}},
+		},
+		{
+			name:    "semicolon delimiter",
+			input:   "name;score\nalpha;1,5\n",
+			options: &CsvOptions{Delimiter: ';'},
+			want:    [][]string{{"name", "score"}, {"alpha", "1,5"}},
+		},
+		{
+			name:    "tab delimiter with comments",
+			input:   "# exported\nname\tscore\nalpha\t2\n",
+			options: &CsvOptions{Delimiter: '\t', Comment: '#'},
+			want:    [][]string{{"name", "score"}, {"alpha", "2"}},
+		},
+		{
+			name:    "no header",
+			input:   "alpha,1,x\nbeta,2,y\n",
+			options: &CsvOptions{NoHeader: true},
+			want:    [][]string{{"A", "B", "C"}, {"alpha", "1", "x"}, {"beta", "2", "y"}},
+		},
+		{
+			name:    "no header and no rows",
+			input:   "",
+			options: &CsvOptions{NoHeader: true},
+			want:    nil,
+		},
+		{
+			name:    "lazy quotes",
+			input:   "name\nsay \"hi\"\n",
+			options: &CsvOptions{LazyQuotes: true},
+			want:    [][]string{{"name"}, {"say \"hi\""}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table, err := ReadAll(NewCsvSource(io.NopCloser(strings.NewReader(test.input)), test.options))
+			if err != nil {
+				t.Fatalf("ReadAll: %s", err.Error())
+			}
+			if !reflect.DeepEqual(table, test.want) {
+				t.Errorf("got %q, want %q", table, test.want)
+			}
+		})
+	}
+}
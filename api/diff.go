@@ -0,0 +1,251 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/api/sheets/v4"
+	"strconv"
+	"strings"
+)
+
+// Diff compares the uploaded table with the table of the previous run, matching rows by KeyColumn
+type Diff struct {
+	KeyColumn string `json:"key_column"`
+	// Background of changed cells. Defaults to light yellow
+	Color *Color `json:"color"`
+	// Title of the tab listing added and removed rows. Defaults to Changes
+	ChangesTab string `json:"changes_tab"`
+}
+
+// CellChange is a cell of the new table whose value differs from the cell of the same row and column in the previous table
+type CellChange struct {
+	// Index of the row and column in the new table
+	Row      int
+	Column   int
+	Previous string
+}
+
+// TableDiff holds the differences between the previous and the new table
+type TableDiff struct {
+	Changes []*CellChange
+	// Rows of the new table without a row of the same key in the previous table
+	Added [][]string
+	// Rows of the previous table without a row of the same key in the new table, with the columns of the new table
+	Removed [][]string
+}
+
+// DefaultDiffColor is the background of changed cells
+func DefaultDiffColor() *Color {
+	return &Color{
+		R: 1,
+		G: 0.95,
+		B: 0.6,
+		A: 1,
+	}
+}
+
+// ReadSheet returns the unformatted values of the sheet with the given title, or no rows if the sheet is missing.
+// Numbers are read without their number format, dates and times as formatted.
+func (s *Service) ReadSheet(spreadsheetId string, title string) ([][]string, error) {
+	sheetsProperties, err := s.getSheetsProperties(spreadsheetId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = findSheetProperties(sheetsProperties, title)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.sheetsService().Spreadsheets.Values.Get(spreadsheetId, sheetRange(title)).ValueRenderOption("UNFORMATTED_VALUE").
+		DateTimeRenderOption("FORMATTED_STRING").Do()
+	if err != nil {
+		return nil, s.sheetsError(err, spreadsheetId, title)
+	}
+	var table [][]string
+	for _, rowRaw := range resp.Values {
+		row := make([]string, len(rowRaw))
+		for i, value := range rowRaw {
+			row[i] = unformattedCell(value)
+		}
+		table = append(table, row)
+	}
+	return table, nil
+}
+
+// DiffTables matches the rows of both tables by their value in keyColumn, pairing repeated keys in order of appearance,
+// and compares the cells of the columns in both tables. Formula cells of the new table are not compared.
+func DiffTables(previous [][]string, table [][]string, keyColumn string) (*TableDiff, error) {
+	if len(table) == 0 {
+		return nil, &Error{
+			Kind: ErrEmptyTable,
+		}
+	}
+	key := sliceIndex(table[0], keyColumn)
+	if key < 0 {
+		return nil, missingColumnError(keyColumn)
+	}
+	diff := &TableDiff{}
+	if len(previous) == 0 {
+		diff.Added = table[1:]
+		return diff, nil
+	}
+	previousKey := sliceIndex(previous[0], keyColumn)
+	if previousKey < 0 {
+		return nil, &Error{
+			Kind:   ErrMissingColumn,
+			Column: keyColumn,
+			Err:    errors.New("not in the previous table"),
+		}
+	}
+
+	// previousColumns maps each column of the new table to the same column of the previous table, or -1
+	previousColumns := make([]int, len(table[0]))
+	for i, column := range table[0] {
+		previousColumns[i] = sliceIndex(previous[0], column)
+	}
+
+	previousRows := make(map[string][]int)
+	for i := 1; i < len(previous); i++ {
+		value := cell(previous[i], previousKey)
+		previousRows[value] = append(previousRows[value], i)
+	}
+	matched := make([]bool, len(previous))
+	for i := 1; i < len(table); i++ {
+		value := cell(table[i], key)
+		if len(previousRows[value]) == 0 {
+			diff.Added = append(diff.Added, table[i])
+			continue
+		}
+		previousRow := previous[previousRows[value][0]]
+		matched[previousRows[value][0]] = true
+		previousRows[value] = previousRows[value][1:]
+
+		for j, previousColumn := range previousColumns {
+			current := cell(table[i], j)
+			if previousColumn < 0 || strings.HasPrefix(current, "=") {
+				continue
+			}
+			if !sameValue(cell(previousRow, previousColumn), current) {
+				diff.Changes = append(diff.Changes, &CellChange{
+					Row:      i,
+					Column:   j,
+					Previous: cell(previousRow, previousColumn),
+				})
+			}
+		}
+	}
+
+	for i := 1; i < len(previous); i++ {
+		if matched[i] {
+			continue
+		}
+		row := make([]string, len(table[0]))
+		for j, previousColumn := range previousColumns {
+			if previousColumn >= 0 {
+				row[j] = cell(previous[i], previousColumn)
+			}
+		}
+		diff.Removed = append(diff.Removed, row)
+	}
+	return diff, nil
+}
+
+// ChangesTable returns the added and removed rows under the header, each labelled in a leading Change column
+func (d *TableDiff) ChangesTable(header []string) [][]string {
+	table := [][]string{append([]string{"Change"}, header...)}
+	for _, row := range d.Added {
+		table = append(table, append([]string{"added"}, row...))
+	}
+	for _, row := range d.Removed {
+		table = append(table, append([]string{"removed"}, row...))
+	}
+	return table
+}
+
+// AnnotateChanges colors each changed cell of the table inserted at cellPosition and adds a note with its previous value
+func (s *Service) AnnotateChanges(spreadsheetId string, cellPosition *CellPosition, changes []*CellChange, color *Color) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	var requests []*sheets.Request
+	for _, change := range changes {
+		position := cellPosition.Offset(change.Row, change.Column)
+		previous := change.Previous
+		if previous == "" {
+			previous = "(empty)"
+		}
+		requests = append(requests, &sheets.Request{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Cell: &sheets.CellData{
+					Note: "Previous value: " + previous,
+					UserEnteredFormat: &sheets.CellFormat{
+						BackgroundColor: color.toSheetsColor(),
+					},
+				},
+				Fields: "note,userEnteredFormat.backgroundColor",
+				Range:  toGridRange(sheetId, position, position),
+			},
+		})
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+// unformattedCell formats a value read with UNFORMATTED_VALUE without an exponent, and booleans as sheets shows them
+func unformattedCell(value interface{}) string {
+	switch typed := value.(type) {
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		if typed {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+// sameValue compares an unformatted previous value with a new value, which sheets parses as a number when it has
+// thousands separators, a currency symbol, a trailing % or an exponent
+func sameValue(previous string, current string) bool {
+	if previous == current {
+		return true
+	}
+	previousNumber, isNumber := parseNumber(previous)
+	if !isNumber {
+		return false
+	}
+	currentNumber, isNumber := enteredNumber(current)
+	return isNumber && previousNumber == currentNumber
+}
+
+// enteredNumber parses a value as sheets parses numbers entered by users
+func enteredNumber(value string) (float64, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	negative := strings.HasPrefix(value, "-")
+	if negative {
+		value = value[1:]
+	}
+	value = strings.TrimLeft(value, "$€£¥")
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+		return 0, false
+	}
+	number, isNumber := parseNumber(value)
+	if negative {
+		number = -number
+	}
+	return number, isNumber
+}
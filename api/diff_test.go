@@ -0,0 +1,83 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDiffTablesFormattedNumbers(t *testing.T) {
+	previous := [][]string{
+		{"id", "amount", "share", "price", "large", "date"},
+		{"a", "1234.5", "0.1", "-5", "1200000", "2024-01-05"},
+		{"b", "7", "0.25", "3", "12", "2024-01-06"},
+	}
+	table := [][]string{
+		{"id", "amount", "share", "price", "large", "date"},
+		{"a", "1,234.5", "10%", "-$5", "1.2e6", "2024-01-05"},
+		{"b", "8", "25%", "$4", "12", "2024-01-07"},
+	}
+	diff, err := DiffTables(previous, table, "id")
+	if err != nil {
+		t.Fatalf("DiffTables: %s", err.Error())
+	}
+	var changed [][2]int
+	for _, change := range diff.Changes {
+		changed = append(changed, [2]int{change.Row, change.Column})
+	}
+	want := [][2]int{{2, 1}, {2, 3}, {2, 5}}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("got changes %v, want %v", changed, want)
+	}
+}
+
+func TestSameValue(t *testing.T) {
+	tests := []struct {
+		previous string
+		current  string
+		want     bool
+	}{
+		{"1234.5", "1,234.5", true},
+		{"0.1", "10%", true},
+		{"5", "$5", true},
+		{"-5", "-$5", true},
+		{"1200000", "1.2e6", true},
+		{"5", "$-5", false},
+		{"5", "6", false},
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+		{"TRUE", "TRUE", true},
+	}
+	for _, test := range tests {
+		if got := sameValue(test.previous, test.current); got != test.want {
+			t.Errorf("sameValue(%q, %q) = %t, want %t", test.previous, test.current, got, test.want)
+		}
+	}
+}
+
+func TestReadSheetUnformatted(t *testing.T) {
+	s := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fields") != "" {
+			io.WriteString(w, `{"sheets": [{"properties": {"sheetId": 0, "title": "Sheet1", "gridProperties": {"rowCount": 1000, "columnCount": 26}}}]}`)
+			return
+		}
+		if r.URL.Query().Get("valueRenderOption") != "UNFORMATTED_VALUE" || r.URL.Query().Get("dateTimeRenderOption") != "FORMATTED_STRING" {
+			t.Errorf("unexpected render options %s", r.URL.RawQuery)
+		}
+		io.WriteString(w, `{"values": [["id", "amount", "ok", "date"], ["a", 12345678901234567890, true, "2024-01-05"], ["b", 0.0000001, false]]}`)
+	})
+
+	table, err := s.ReadSheet("id", "Sheet1")
+	if err != nil {
+		t.Fatalf("ReadSheet: %s", err.Error())
+	}
+	want := [][]string{
+		{"id", "amount", "ok", "date"},
+		{"a", "12345678901234567000", "TRUE", "2024-01-05"},
+		{"b", "0.0000001", "FALSE"},
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("got %q, want %q", table, want)
+	}
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"google.golang.org/api/gmail/v1"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Email is a message with a plain text body, an HTML body, or both as alternatives
+type Email struct {
+	// Defaults to the account sending the email
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+	Subject string
+	Text    string
+	HTML    string
+	// Attached files
+	Attachments []*Attachment
+}
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename string
+	// Defaults to the type of the file extension
+	MimeType string
+	Data     []byte
+}
+
+// mimePart is a MIME entity: its headers, in order, and its encoded body
+type mimePart struct {
+	header [][2]string
+	body   []byte
+}
+
+// Bytes returns the email as an RFC 5322 message with MIME parts
+func (e *Email) Bytes() ([]byte, error) {
+	return e.message(true)
+}
+
+// message returns the email as an RFC 5322 message, leaving out the Bcc header when sent to the recipients by SMTP
+func (e *Email) message(bcc bool) ([]byte, error) {
+	if len(e.To)+len(e.Cc)+len(e.Bcc) == 0 {
+		return nil, validationErrorf("email has no recipients")
+	}
+
+	var buffer bytes.Buffer
+	writeHeader := func(name string, value string) {
+		buffer.WriteString(name + ": " + value + "\r\n")
+	}
+	writeAddresses := func(name string, addresses []string) error {
+		if len(addresses) == 0 {
+			return nil
+		}
+		var formatted []string
+		for _, address := range addresses {
+			parsed, err := mail.ParseAddress(address)
+			if err != nil {
+				return validationErrorf("invalid %s address %s: %w", name, address, err)
+			}
+			formatted = append(formatted, parsed.String())
+		}
+		writeHeader(name, strings.Join(formatted, ", "))
+		return nil
+	}
+
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	for _, addresses := range []struct {
+		name      string
+		addresses []string
+	}{
+		{"From", nonEmpty(e.From)},
+		{"To", e.To},
+		{"Cc", e.Cc},
+		{"Bcc", bccHeader(e.Bcc, bcc)},
+		{"Reply-To", nonEmpty(e.ReplyTo)},
+	} {
+		err := writeAddresses(addresses.name, addresses.addresses)
+		if err != nil {
+			return nil, err
+		}
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", e.Subject))
+
+	part, err := e.bodyPart()
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range part.header {
+		writeHeader(header[0], header[1])
+	}
+	buffer.WriteString("\r\n")
+	buffer.Write(part.body)
+	return buffer.Bytes(), nil
+}
+
+// Send sends the email with Gmail as the authenticated user
+func (s *Service) Send(email *Email) error {
+	raw, err := email.Bytes()
+	if err != nil {
+		return err
+	}
+	_, err = s.gmailService().Users.Messages.Send("me", &gmail.Message{
+		Raw: base64.URLEncoding.EncodeToString(raw),
+	}).Do()
+	return s.apiError(err, GmailSendScope, "", "")
+}
+
+// bodyPart returns the text and HTML alternatives, followed by the attachments when there are any
+func (e *Email) bodyPart() (*mimePart, error) {
+	var alternatives []*mimePart
+	if e.Text != "" || e.HTML == "" {
+		alternatives = append(alternatives, textPart("text/plain", e.Text))
+	}
+	if e.HTML != "" {
+		alternatives = append(alternatives, textPart("text/html", e.HTML))
+	}
+
+	body := alternatives[0]
+	if len(alternatives) > 1 {
+		var err error
+		body, err = multipartPart("alternative", alternatives)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(e.Attachments) == 0 {
+		return body, nil
+	}
+
+	parts := []*mimePart{body}
+	for _, attachment := range e.Attachments {
+		parts = append(parts, attachmentPart(attachment))
+	}
+	return multipartPart("mixed", parts)
+}
+
+func textPart(mimeType string, text string) *mimePart {
+	var body bytes.Buffer
+	writer := quotedprintable.NewWriter(&body)
+	writer.Write([]byte(text))
+	writer.Close()
+	return &mimePart{
+		header: [][2]string{
+			{"Content-Type", mimeType + "; charset=utf-8"},
+			{"Content-Transfer-Encoding", "quoted-printable"},
+		},
+		body: body.Bytes(),
+	}
+}
+
+func attachmentPart(attachment *Attachment) *mimePart {
+	mimeType := attachment.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+	}
+	// the type of an extension may have parameters, like charset
+	mimeType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mimeType = "application/octet-stream"
+	}
+
+	// base64 lines are at most 76 characters
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	var body bytes.Buffer
+	for len(encoded) > 76 {
+		body.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	body.WriteString(encoded + "\r\n")
+
+	return &mimePart{
+		header: [][2]string{
+			{"Content-Type", mime.FormatMediaType(mimeType, map[string]string{"name": attachment.Filename})},
+			{"Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			{"Content-Transfer-Encoding", "base64"},
+		},
+		body: body.Bytes(),
+	}
+}
+
+func multipartPart(subtype string, parts []*mimePart) (*mimePart, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		for _, field := range part.header {
+			header.Set(field[0], field[1])
+		}
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		_, err = partWriter.Write(part.body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &mimePart{
+		header: [][2]string{
+			{"Content-Type", "multipart/" + subtype + "; boundary=" + writer.Boundary()},
+		},
+		body: body.Bytes(),
+	}, nil
+}
+
+func bccHeader(addresses []string, bcc bool) []string {
+	if !bcc {
+		return nil
+	}
+	return addresses
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"html/template"
+	"math"
+	"os"
+)
+
+// EmailOptions configure the HTML body of the report email
+type EmailOptions struct {
+	// html/template file rendered with EmailData. Defaults to a summary of the table
+	Template string `json:"template"`
+	// Number of table rows shown. Defaults to 10
+	TopRows int `json:"top_rows"`
+	// Attaches the spreadsheet exported as pdf, xlsx or csv
+	Attach string `json:"attach"`
+	// Largest attachment, above which the email only has the link. Defaults to 10 MB
+	MaxAttachmentBytes int64 `json:"max_attachment_bytes"`
+}
+
+// EmailData is the report data available to email templates
+type EmailData struct {
+	Title   string
+	Link    string
+	Message string
+	Header  []string
+	// First rows of the table, after the header
+	TopRows [][]string
+	// Number of rows of the table, after the header
+	Rows int
+	// Stats of each column, in header order
+	Columns []*ColumnStats
+	// Titles of the charts
+	Charts []string
+}
+
+// ColumnStats summarize the numbers of a column. Numeric is false when the column has no numbers,
+// or has a cell which is neither empty nor a number.
+type ColumnStats struct {
+	Name    string
+	Numeric bool
+	// Number of non-empty cells
+	Count   int
+	Sum     float64
+	Min     float64
+	Max     float64
+	Average float64
+}
+
+var emailFuncs = template.FuncMap{
+	// number formats a float with at most 2 decimals
+	"number": func(number float64) string {
+		return formatNumber(math.Round(number*100) / 100)
+	},
+}
+
+var defaultEmailTemplate = template.Must(template.New("email").Funcs(emailFuncs).Parse(`<html>
+<body style="font-family: Arial, sans-serif">
+<h2>{{.Title}}</h2>
+<p><a href="{{.Link}}">Open the report</a></p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{if .Header}}
+<table style="border-collapse: collapse" cellpadding="4" border="1">
+<tr>{{range .Header}}<th style="background: #eeeeee">{{.}}</th>{{end}}</tr>
+{{range .TopRows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}<tr>{{range .Columns}}<td><b>{{if .Numeric}}{{number .Sum}}{{end}}</b></td>{{end}}</tr>
+</table>
+<p>{{if gt .Rows (len .TopRows)}}Showing {{len .TopRows}} of {{.Rows}} rows, with totals of all rows.{{else}}{{.Rows}} rows, with totals.{{end}}</p>
+<table style="border-collapse: collapse" cellpadding="4" border="1">
+<tr><th>Column</th><th>Min</th><th>Max</th><th>Average</th></tr>
+{{range .Columns}}{{if .Numeric}}<tr><td>{{.Name}}</td><td>{{number .Min}}</td><td>{{number .Max}}</td><td>{{number .Average}}</td></tr>
+{{end}}{{end}}</table>
+{{end}}
+{{if .Charts}}<p>Charts:</p>
+<ul>{{range .Charts}}<li>{{.}}</li>{{end}}</ul>{{end}}
+</body>
+</html>
+`))
+
+// NewEmailData collects the data of the table, including its header, for email templates
+func NewEmailData(title string, link string, message string, table [][]string, topRows int, charts []*Chart) *EmailData {
+	data := &EmailData{
+		Title:   title,
+		Link:    link,
+		Message: message,
+	}
+	for _, chart := range charts {
+		data.Charts = append(data.Charts, chart.Title)
+	}
+	if len(table) == 0 {
+		return data
+	}
+
+	if topRows <= 0 {
+		topRows = 10
+	}
+	data.Header = table[0]
+	data.Rows = len(table) - 1
+	for i := 1; i < len(table) && i <= topRows; i++ {
+		data.TopRows = append(data.TopRows, table[i])
+	}
+	for i, name := range table[0] {
+		stats := &ColumnStats{
+			Name:    name,
+			Numeric: true,
+		}
+		for _, row := range table[1:] {
+			value := cell(row, i)
+			if value == "" {
+				continue
+			}
+			number, isNumber := parseNumber(value)
+			if !isNumber {
+				stats.Numeric = false
+				break
+			}
+			if stats.Count == 0 || number < stats.Min {
+				stats.Min = number
+			}
+			if stats.Count == 0 || number > stats.Max {
+				stats.Max = number
+			}
+			stats.Count++
+			stats.Sum += number
+		}
+		// a column of empty cells has no numbers
+		stats.Numeric = stats.Numeric && stats.Count > 0
+		if stats.Numeric {
+			stats.Average = stats.Sum / float64(stats.Count)
+		}
+		data.Columns = append(data.Columns, stats)
+	}
+	return data
+}
+
+// RenderEmail renders the html/template file with the data, or the default template when templateFile is empty
+func RenderEmail(templateFile string, data *EmailData) (string, error) {
+	emailTemplate := defaultEmailTemplate
+	if templateFile != "" {
+		b, err := os.ReadFile(templateFile)
+		if err != nil {
+			return "", err
+		}
+		emailTemplate, err = template.New(templateFile).Funcs(emailFuncs).Parse(string(b))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var buffer bytes.Buffer
+	err := emailTemplate.Execute(&buffer, data)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
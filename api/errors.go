@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/api/googleapi"
+	"net/http"
+	"strings"
+)
+
+// Kinds of failure of Service and the functions of this package. Errors of a kind match it with errors.Is, e.g.
+// errors.Is(err, ErrNotFound). errors.As(err, &e) with e an *Error gives the spreadsheet, range or column at fault,
+// and the cause, e.g. the *googleapi.Error of the response, is unwrapped with errors.Unwrap or matched with errors.As.
+// A *MissingScopeError matches ErrPermissionDenied.
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrQuotaExceeded    = errors.New("quota exceeded")
+	ErrInvalidRange     = errors.New("invalid range")
+	ErrMissingColumn    = errors.New("missing column")
+	ErrEmptyTable       = errors.New("empty table")
+	ErrValidation       = errors.New("validation failed")
+)
+
+var (
+	errNoSheets = errors.New("0 sheets")
+	// the sheet holds an object, e.g. a chart, instead of cells
+	errNoGrid = errors.New("sheet has no grid")
+)
+
+// Error is a failure of Kind, with what it concerns when known
+type Error struct {
+	Kind          error
+	SpreadsheetId string
+	// Range in A1 notation, or the title of a sheet
+	Range  string
+	Column string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	message := e.Kind.Error()
+	if e.Column != "" {
+		message += ": " + e.Column
+	}
+	if e.Range != "" {
+		message += ": " + e.Range
+	}
+	if e.SpreadsheetId != "" {
+		message += " (spreadsheet " + e.SpreadsheetId + ")"
+	}
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the kind of the error
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *MissingScopeError) Is(target error) bool {
+	return target == ErrPermissionDenied
+}
+
+func missingColumnError(column string) error {
+	return &Error{
+		Kind:   ErrMissingColumn,
+		Column: column,
+	}
+}
+
+func noSheetsError(spreadsheetId string) error {
+	return &Error{
+		Kind:          ErrNotFound,
+		SpreadsheetId: spreadsheetId,
+		Err:           errNoSheets,
+	}
+}
+
+func validationErrorf(format string, a ...interface{}) error {
+	return &Error{
+		Kind: ErrValidation,
+		Err:  fmt.Errorf(format, a...),
+	}
+}
+
+// Reasons of denied requests which exceeded a quota rather than lacked permission
+var quotaReasons = []string{"rateLimitExceeded", "userRateLimitExceeded", "dailyLimitExceeded", "quotaExceeded", "sharingRateLimitExceeded"}
+
+// Reasons of denied requests which lacked permission on the file or were refused by the domain
+var permissionReasons = []string{"forbidden", "insufficientPermissions", "insufficientFilePermissions", "appNotAuthorizedToFile", "domainPolicy"}
+
+// apiError returns the Error of a failed request to the API whose operations need scope, concerning the spreadsheet
+// or file and the range when known, or MissingScopeError when the token lacks the scope.
+// Other errors, e.g. of a kind which is not known, are returned unchanged.
+func (s *Service) apiError(err error, scope string, spreadsheetId string, rangeName string) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	if apiErr.Code == http.StatusForbidden &&
+		(strings.Contains(apiErr.Body, "ACCESS_TOKEN_SCOPE_INSUFFICIENT") || strings.Contains(apiErr.Body, "insufficient authentication scopes")) {
+		return &MissingScopeError{
+			Scope:     scope,
+			Requested: sliceIndex(s.scopes, scope) >= 0,
+		}
+	}
+	kind := errorKind(apiErr)
+	if kind == nil {
+		return err
+	}
+	return &Error{
+		Kind:          kind,
+		SpreadsheetId: spreadsheetId,
+		Range:         rangeName,
+		Err:           apiErr,
+	}
+}
+
+// sheetsError returns the Error of a failed Sheets request on the spreadsheet and range
+func (s *Service) sheetsError(err error, spreadsheetId string, rangeName string) error {
+	return s.apiError(err, SheetsScope, spreadsheetId, rangeName)
+}
+
+// driveError returns the Error of a failed Drive request on the file
+func (s *Service) driveError(err error, fileId string) error {
+	return s.apiError(err, DriveScope, fileId, "")
+}
+
+// errorKind returns the kind of the error of a response, or nil when it is not known.
+// Denied requests are classified by their reasons, or by their status when Sheets gives no reason.
+func errorKind(apiErr *googleapi.Error) error {
+	switch apiErr.Code {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrQuotaExceeded
+	case http.StatusUnauthorized:
+		return ErrPermissionDenied
+	case http.StatusForbidden:
+		var kind error
+		if errorStatus(apiErr) == "PERMISSION_DENIED" {
+			kind = ErrPermissionDenied
+		}
+		for _, item := range apiErr.Errors {
+			switch {
+			case sliceIndex(quotaReasons, item.Reason) >= 0:
+				return ErrQuotaExceeded
+			// Drive denies exports over its own size limit
+			case item.Reason == "exportSizeLimitExceeded":
+				return ErrExportTooLarge
+			case sliceIndex(permissionReasons, item.Reason) >= 0:
+				kind = ErrPermissionDenied
+			}
+		}
+		return kind
+	case http.StatusBadRequest:
+		if strings.Contains(apiErr.Message, "Unable to parse range") || strings.Contains(apiErr.Message, "exceeds grid limits") {
+			return ErrInvalidRange
+		}
+	}
+	return nil
+}
+
+// errorStatus returns the status in the body of a failed response, e.g. PERMISSION_DENIED
+func errorStatus(apiErr *googleapi.Error) string {
+	var body struct {
+		Error struct {
+			Status string `json:"status"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &body) != nil {
+		return ""
+	}
+	return body.Error.Status
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testService returns a service whose Sheets and Drive clients send their requests to the handler
+func testService(t *testing.T, handler http.HandlerFunc) *Service {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	s := &Service{
+		ctx:         context.Background(),
+		tokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		scopes:      []string{DriveScope},
+	}
+	s.sheetsOnce.Do(func() {
+		s.sheets, _ = sheets.NewService(s.ctx, s.clientOption(), option.WithEndpoint(server.URL+"/"))
+	})
+	s.driveOnce.Do(func() {
+		s.drive, _ = drive.NewService(s.ctx, s.clientOption(), option.WithEndpoint(server.URL+"/drive/v3/"))
+	})
+	return s
+}
+
+// respond writes a failed response with the JSON error body of the Google APIs
+func respond(w http.ResponseWriter, code int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	io.WriteString(w, body)
+}
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		body string
+		want error
+	}{
+		{"not found", 404, `{"error": {"code": 404, "message": "Requested entity was not found.", "status": "NOT_FOUND"}}`, ErrNotFound},
+		{"too many requests", 429, `{"error": {"code": 429, "message": "Quota exceeded", "status": "RESOURCE_EXHAUSTED"}}`, ErrQuotaExceeded},
+		{"unauthenticated", 401, `{"error": {"code": 401, "message": "Invalid Credentials", "errors": [{"reason": "authError"}]}}`, ErrPermissionDenied},
+		{"drive rate limit", 403, `{"error": {"code": 403, "message": "Rate limit exceeded", "errors": [{"reason": "userRateLimitExceeded"}]}}`, ErrQuotaExceeded},
+		{"export size limit", 403, `{"error": {"code": 403, "message": "This file is too large to be exported.", "errors": [{"reason": "exportSizeLimitExceeded"}]}}`, ErrExportTooLarge},
+		{"drive permission", 403, `{"error": {"code": 403, "message": "The user does not have sufficient permissions for this file.", "errors": [{"reason": "insufficientFilePermissions"}]}}`, ErrPermissionDenied},
+		{"sheets permission", 403, `{"error": {"code": 403, "message": "The caller does not have permission", "status": "PERMISSION_DENIED"}}`, ErrPermissionDenied},
+		{"unknown reason", 403, `{"error": {"code": 403, "message": "This file cannot be downloaded.", "errors": [{"reason": "cannotDownloadAbusiveFile"}]}}`, nil},
+		{"unparsable range", 400, `{"error": {"code": 400, "message": "Unable to parse range: Data!A0", "status": "INVALID_ARGUMENT"}}`, ErrInvalidRange},
+		{"grid limits", 400, `{"error": {"code": 400, "message": "Range ('Sheet1'!A1:B2000) exceeds grid limits. Max rows: 1000", "status": "INVALID_ARGUMENT"}}`, ErrInvalidRange},
+		{"bad request", 400, `{"error": {"code": 400, "message": "Invalid value at 'data.values'", "status": "INVALID_ARGUMENT"}}`, nil},
+		{"server error", 500, `{"error": {"code": 500, "message": "Internal error encountered.", "status": "INTERNAL"}}`, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := googleapi.CheckResponse(&http.Response{
+				StatusCode: test.code,
+				Body:       io.NopCloser(strings.NewReader(test.body)),
+			})
+			var apiErr *googleapi.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("CheckResponse returned %v", err)
+			}
+			got := errorKind(apiErr)
+			if got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestServiceErrors(t *testing.T) {
+	s := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v4/spreadsheets/missing"):
+			respond(w, 404, `{"error": {"code": 404, "message": "Requested entity was not found.", "status": "NOT_FOUND"}}`)
+		case strings.HasPrefix(r.URL.Path, "/v4/spreadsheets/small/values/"):
+			respond(w, 400, `{"error": {"code": 400, "message": "Range ('Sheet1'!A1:B2) exceeds grid limits. Max rows: 1", "status": "INVALID_ARGUMENT"}}`)
+		case strings.HasPrefix(r.URL.Path, "/v4/spreadsheets/"):
+			respond(w, 403, `{"error": {"code": 403, "message": "Request had insufficient authentication scopes.", "status": "PERMISSION_DENIED",
+				"details": [{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "ACCESS_TOKEN_SCOPE_INSUFFICIENT"}]}}`)
+		default:
+			respond(w, 403, `{"error": {"code": 403, "message": "This file cannot be downloaded.", "errors": [{"reason": "cannotDownloadAbusiveFile"}]}}`)
+		}
+	})
+
+	_, err := s.getSheetsProperties("missing")
+	var serviceErr *Error
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &serviceErr) || serviceErr.SpreadsheetId != "missing" {
+		t.Errorf("got %v, want ErrNotFound of spreadsheet missing", err)
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 404 {
+		t.Errorf("got %v, want the cause to be the 404 response", err)
+	}
+	// the error of the API is not wrapped in the *url.Error of a failed request
+	if strings.Contains(err.Error(), "http://") {
+		t.Errorf("got message %q, want no request URL", err.Error())
+	}
+
+	err = s.writeTable("small", "", Origin(), [][]string{{"a", "b"}, {"1", "2"}})
+	if !errors.Is(err, ErrInvalidRange) || !errors.As(err, &serviceErr) || serviceErr.Range != "A1:B2" {
+		t.Errorf("got %v, want ErrInvalidRange of A1:B2", err)
+	}
+
+	_, err = s.getSheetsProperties("other")
+	var missingScope *MissingScopeError
+	if !errors.As(err, &missingScope) || missingScope.Scope != SheetsScope || missingScope.Requested {
+		t.Errorf("got %v, want MissingScopeError of the unrequested scope %s", err, SheetsScope)
+	}
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("got %v, want MissingScopeError to match ErrPermissionDenied", err)
+	}
+
+	_, err = s.ExportAttachment("abusive", "Report", "pdf", 1<<20)
+	if errors.Is(err, ErrPermissionDenied) || errors.As(err, &serviceErr) {
+		t.Errorf("got %v, want a denial of unknown reason to keep its kind unknown", err)
+	}
+	if !errors.As(err, &apiErr) || apiErr.Code != 403 {
+		t.Errorf("got %v, want the 403 response", err)
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrExportTooLarge is returned by ExportAttachment when the exported file is larger than the limit,
+// or than the limit of Drive on exports
+var ErrExportTooLarge = errors.New("exported file is too large")
+
+// Mime types of the formats a spreadsheet exports to
+var exportMimeTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"csv":  "text/csv",
+}
+
+// ExportAttachment exports the spreadsheet with Drive as pdf or xlsx, of every sheet, or csv, of the first sheet only,
+// and returns it as an attachment named title with the format's extension.
+// It returns ErrExportTooLarge when it is larger than maxBytes or Drive refuses to export that much.
+func (s *Service) ExportAttachment(spreadsheetId string, title string, format string, maxBytes int64) (*Attachment, error) {
+	mimeType, ok := exportMimeTypes[format]
+	if !ok {
+		return nil, validationErrorf("unknown export format: %s", format)
+	}
+
+	resp, err := s.driveService().Files.Export(spreadsheetId, mimeType).Download()
+	if err != nil {
+		return nil, s.driveError(err, spreadsheetId)
+	}
+	defer resp.Body.Close()
+
+	if resp.ContentLength > maxBytes {
+		return nil, ErrExportTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrExportTooLarge
+	}
+	return &Attachment{
+		Filename: strings.ReplaceAll(title, "/", "-") + "." + format,
+		MimeType: mimeType,
+		Data:     data,
+	}, nil
+}
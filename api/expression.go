@@ -0,0 +1,170 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// expression is arithmetic over numbers and column references in braces, e.g. ({Current} - {Previous}) / {Previous}.
+// It supports +, -, *, / and parentheses with the usual precedence.
+type expression struct {
+	tokens []string
+	// index in the header of each column referenced, by token
+	columns map[string]int
+}
+
+// parseExpression tokenizes the expression and resolves its column references against header
+func parseExpression(header []string, source string) (*expression, error) {
+	e := &expression{
+		columns: make(map[string]int),
+	}
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("+-*/()", c) >= 0:
+			e.tokens = append(e.tokens, string(c))
+			i++
+		case c == '{':
+			end := strings.IndexByte(source[i:], '}')
+			if end < 0 {
+				return nil, validationErrorf("unclosed column reference in expression: %s", source)
+			}
+			token := source[i : i+end+1]
+			column := token[1 : len(token)-1]
+			index := sliceIndex(header, column)
+			if index < 0 {
+				return nil, missingColumnError(column)
+			}
+			e.columns[token] = index
+			e.tokens = append(e.tokens, token)
+			i += end + 1
+		case c == '.' || (c >= '0' && c <= '9'):
+			end := i
+			for end < len(source) && (source[end] == '.' || (source[end] >= '0' && source[end] <= '9')) {
+				end++
+			}
+			e.tokens = append(e.tokens, source[i:end])
+			i = end
+		default:
+			return nil, validationErrorf("unexpected character %c in expression: %s", c, source)
+		}
+	}
+
+	// evaluate once with every column set to 1 to reject malformed expressions before any row is read.
+	// evaluate checks the syntax of the whole expression before it reports errNotANumber.
+	_, err := e.evaluate(nil, true)
+	if err != nil && !errors.Is(err, errNotANumber) {
+		return nil, validationErrorf("invalid expression %s: %w", source, err)
+	}
+	return e, nil
+}
+
+var errNotANumber = errors.New("not a number")
+
+// evaluate computes the expression for a row. When dryRun is set, column references evaluate to 1.
+// It returns errNotANumber when a referenced value is not a number, the expression divides by zero or the result is
+// not finite, but only once the whole expression has parsed.
+func (e *expression) evaluate(row []string, dryRun bool) (float64, error) {
+	position := 0
+	notANumber := false
+	var parseSum, parseProduct, parseOperand func() (float64, error)
+
+	parseOperand = func() (float64, error) {
+		if position >= len(e.tokens) {
+			return 0, errors.New("unexpected end")
+		}
+		token := e.tokens[position]
+		position++
+		switch {
+		case token == "-":
+			value, err := parseOperand()
+			return -value, err
+		case token == "(":
+			value, err := parseSum()
+			if err != nil {
+				return 0, err
+			}
+			if position >= len(e.tokens) || e.tokens[position] != ")" {
+				return 0, errors.New("missing )")
+			}
+			position++
+			return value, nil
+		case strings.HasPrefix(token, "{"):
+			if dryRun {
+				return 1, nil
+			}
+			value, ok := parseNumber(cell(row, e.columns[token]))
+			if !ok {
+				notANumber = true
+			}
+			return value, nil
+		default:
+			value, err := strconv.ParseFloat(token, 64)
+			if err != nil {
+				return 0, fmt.Errorf("unexpected %s", token)
+			}
+			return value, nil
+		}
+	}
+
+	parseProduct = func() (float64, error) {
+		value, err := parseOperand()
+		if err != nil {
+			return 0, err
+		}
+		for position < len(e.tokens) && (e.tokens[position] == "*" || e.tokens[position] == "/") {
+			operator := e.tokens[position]
+			position++
+			operand, err := parseOperand()
+			if err != nil {
+				return 0, err
+			}
+			if operator == "*" {
+				value *= operand
+			} else if operand == 0 {
+				notANumber = true
+			} else {
+				value /= operand
+			}
+		}
+		return value, nil
+	}
+
+	parseSum = func() (float64, error) {
+		value, err := parseProduct()
+		if err != nil {
+			return 0, err
+		}
+		for position < len(e.tokens) && (e.tokens[position] == "+" || e.tokens[position] == "-") {
+			operator := e.tokens[position]
+			position++
+			operand, err := parseProduct()
+			if err != nil {
+				return 0, err
+			}
+			if operator == "+" {
+				value += operand
+			} else {
+				value -= operand
+			}
+		}
+		return value, nil
+	}
+
+	value, err := parseSum()
+	if err != nil {
+		return 0, err
+	}
+	if position < len(e.tokens) {
+		return 0, fmt.Errorf("unexpected %s", e.tokens[position])
+	}
+	if notANumber || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errNotANumber
+	}
+	return value, nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpressionEvaluate(t *testing.T) {
+	header := []string{"Current", "Previous", "Name"}
+	tests := []struct {
+		expression string
+		row        []string
+		want       float64
+		wantErr    error
+	}{
+		{"({Current} - {Previous}) / {Previous}", []string{"15", "10"}, 0.5, nil},
+		{"1 + 2 * 3", nil, 7, nil},
+		{"(1 + 2) * 3", nil, 9, nil},
+		{"10 - 4 - 3", nil, 3, nil},
+		{"12 / 3 / 2", nil, 2, nil},
+		{"-{Current} + .5", []string{"2"}, -1.5, nil},
+		{"{Current} * 2", []string{"50%"}, 1, nil},
+		{"{Current} / {Previous}", []string{"1", "0"}, 0, errNotANumber},
+		{"{Current} + 1", []string{"abc"}, 0, errNotANumber},
+		{"{Current} + 1", []string{"NaN"}, 0, errNotANumber},
+		{"{Current} + 1", []string{"Inf"}, 0, errNotANumber},
+		{"{Current} + 1", nil, 0, errNotANumber},
+		{"{Current} * {Previous}", []string{"1e308", "10"}, 0, errNotANumber},
+	}
+	for _, test := range tests {
+		e, err := parseExpression(header, test.expression)
+		if err != nil {
+			t.Errorf("parseExpression(%q): %s", test.expression, err.Error())
+			continue
+		}
+		got, err := e.evaluate(test.row, false)
+		if !errors.Is(err, test.wantErr) || got != test.want {
+			t.Errorf("%q over %q = %v, %v, want %v, %v", test.expression, test.row, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	header := []string{"Current", "Previous"}
+	tests := []struct {
+		expression string
+		want       error
+	}{
+		{"{Current} +", ErrValidation},
+		{"{Current} / 0 +", ErrValidation},
+		{"1 / (0 * {Current}) )", ErrValidation},
+		{"({Current}", ErrValidation},
+		{"{Current} {Previous}", ErrValidation},
+		{"1.2.3", ErrValidation},
+		{"{Current", ErrValidation},
+		{"{Current} ^ 2", ErrValidation},
+		{"{Missing} + 1", ErrMissingColumn},
+	}
+	for _, test := range tests {
+		_, err := parseExpression(header, test.expression)
+		if !errors.Is(err, test.want) {
+			t.Errorf("parseExpression(%q) = %v, want %v", test.expression, err, test.want)
+		}
+	}
+
+	_, err := parseExpression(header, "{Current} / 0")
+	if err != nil {
+		t.Errorf("parseExpression of a division by zero: %s", err.Error())
+	}
+}
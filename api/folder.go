@@ -0,0 +1,186 @@
+package api
+
+import (
+	"fmt"
+	"google.golang.org/api/drive/v3"
+	"strings"
+)
+
+const (
+	folderMimeType      = "application/vnd.google-apps.folder"
+	spreadsheetMimeType = "application/vnd.google-apps.spreadsheet"
+)
+
+// FileOptions place a spreadsheet in Drive and describe it
+type FileOptions struct {
+	// Folder id, or shared drive id, holding the spreadsheet. Defaults to the root of My Drive
+	Folder string
+	// Id of the shared drive holding Folder, for searching folders
+	SharedDrive string
+	// Path of folders under Folder, e.g. Reports/Weekly, created if missing
+	FolderPath  string
+	Description string
+	// Custom file properties, visible to all apps
+	Properties map[string]string
+	// Private app property identifying the spreadsheet for FindSpreadsheet
+	Tag string
+}
+
+// App property of spreadsheets holding FileOptions.Tag
+const tagProperty = "reportTag"
+
+// CreateInFolder creates an empty spreadsheet in the folder of the options, with their description and properties
+func (s *Service) CreateInFolder(title string, options *FileOptions) (string, error) {
+	folderId, err := s.resolveFolder(options)
+	if err != nil {
+		return "", err
+	}
+
+	file := &drive.File{
+		Name:          title,
+		MimeType:      spreadsheetMimeType,
+		Description:   options.Description,
+		Properties:    options.Properties,
+		AppProperties: options.appProperties(),
+	}
+	if folderId != "" {
+		file.Parents = []string{folderId}
+	}
+	file, err = s.driveService().Files.Create(file).Fields("id").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", s.driveError(err, "")
+	}
+	return file.Id, nil
+}
+
+// UpdateFile moves the file to the folder of the options, when they have one, and sets their description and properties
+func (s *Service) UpdateFile(fileId string, options *FileOptions) error {
+	folderId, err := s.resolveFolder(options)
+	if err != nil {
+		return err
+	}
+
+	call := s.driveService().Files.Update(fileId, &drive.File{
+		Description:   options.Description,
+		Properties:    options.Properties,
+		AppProperties: options.appProperties(),
+	}).SupportsAllDrives(true)
+	if folderId != "" {
+		file, err := s.driveService().Files.Get(fileId).Fields("parents").SupportsAllDrives(true).Do()
+		if err != nil {
+			return s.driveError(err, fileId)
+		}
+		if sliceIndex(file.Parents, folderId) < 0 {
+			call = call.AddParents(folderId).RemoveParents(strings.Join(file.Parents, ","))
+		}
+	}
+	_, err = call.Do()
+	return s.driveError(err, fileId)
+}
+
+// FindSpreadsheet returns the id of the most recently modified spreadsheet with the title, in the folder of the options,
+// and with their tag, ignoring the title, folder or tag when empty. It returns an empty id when there is no such spreadsheet.
+func (s *Service) FindSpreadsheet(title string, options *FileOptions) (string, error) {
+	folderId, err := s.resolveFolder(options)
+	if err != nil {
+		return "", err
+	}
+
+	query := fmt.Sprintf("mimeType = '%s' and trashed = false", spreadsheetMimeType)
+	if title != "" {
+		query += fmt.Sprintf(" and name = '%s'", escapeQuery(title))
+	}
+	if folderId != "" {
+		query += fmt.Sprintf(" and '%s' in parents", escapeQuery(folderId))
+	}
+	if options.Tag != "" {
+		query += fmt.Sprintf(" and appProperties has { key='%s' and value='%s' }", tagProperty, escapeQuery(options.Tag))
+	}
+	call := s.driveService().Files.List().Q(query).OrderBy("modifiedTime desc").Fields("files(id)").PageSize(1).
+		SupportsAllDrives(true).IncludeItemsFromAllDrives(true)
+	if options.SharedDrive != "" {
+		call = call.Corpora("drive").DriveId(options.SharedDrive)
+	}
+	list, err := call.Do()
+	if err != nil {
+		return "", s.driveError(err, "")
+	}
+	if len(list.Files) == 0 {
+		return "", nil
+	}
+	return list.Files[0].Id, nil
+}
+
+// FindOrCreateFolder returns the id of the folder at the slash separated path under parentId, creating missing folders.
+// parentId defaults to the root of My Drive. sharedDriveId is the shared drive holding parentId, if any.
+func (s *Service) FindOrCreateFolder(path string, parentId string, sharedDriveId string) (string, error) {
+	if parentId == "" {
+		parentId = "root"
+	}
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+		query := fmt.Sprintf("name = '%s' and mimeType = '%s' and '%s' in parents and trashed = false",
+			escapeQuery(name), folderMimeType, escapeQuery(parentId))
+		call := s.driveService().Files.List().Q(query).Fields("files(id)").PageSize(1).
+			SupportsAllDrives(true).IncludeItemsFromAllDrives(true)
+		if sharedDriveId != "" {
+			call = call.Corpora("drive").DriveId(sharedDriveId)
+		}
+		list, err := call.Do()
+		if err != nil {
+			return "", s.driveError(err, "")
+		}
+		if len(list.Files) > 0 {
+			parentId = list.Files[0].Id
+			continue
+		}
+
+		folder, err := s.driveService().Files.Create(&drive.File{
+			Name:     name,
+			MimeType: folderMimeType,
+			Parents:  []string{parentId},
+		}).Fields("id").SupportsAllDrives(true).Do()
+		if err != nil {
+			return "", fmt.Errorf("failed to create folder %s: %w", name, s.driveError(err, ""))
+		}
+		parentId = folder.Id
+	}
+	return parentId, nil
+}
+
+// TransferOwnership makes the user with the email the owner of the file. Files in shared drives have no owner.
+func (s *Service) TransferOwnership(fileId string, email string) error {
+	return s.SharePermission(fileId, &Permission{
+		Type:         "user",
+		Role:         "owner",
+		EmailAddress: email,
+	}, &ShareOptions{})
+}
+
+// resolveFolder returns the id of the folder of the options, or an empty id for the default folder
+func (s *Service) resolveFolder(options *FileOptions) (string, error) {
+	folderId := options.Folder
+	if folderId == "" {
+		folderId = options.SharedDrive
+	}
+	if options.FolderPath == "" {
+		return folderId, nil
+	}
+	return s.FindOrCreateFolder(options.FolderPath, folderId, options.SharedDrive)
+}
+
+func (o *FileOptions) appProperties() map[string]string {
+	if o.Tag == "" {
+		return nil
+	}
+	return map[string]string{
+		tagProperty: o.Tag,
+	}
+}
+
+// escapeQuery escapes a string value of a Drive search query
+func escapeQuery(value string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), "'", `\'`)
+}
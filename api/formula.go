@@ -0,0 +1,125 @@
+package api
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FormulaColumn is a column appended to the table whose cells are formulas expanded from a template.
+// In the template, {row} is replaced with the row number of each cell, {first_row} and {last_row}
+// with the row numbers of the first and last data rows, and {column:<header>} with the letter of
+// the column named header, e.g. =C{row}-{column:Previous}{row}
+type FormulaColumn struct {
+	Header  string `json:"header"`
+	Formula string `json:"formula"`
+}
+
+// Summary is a footer row below the table aggregating the data rows of its columns
+type Summary struct {
+	// Written to the first column when it is not aggregated. Defaults to Total
+	Label string `json:"label"`
+	// Aggregate function by header name: SUM, AVERAGE, MEDIAN, COUNT, COUNTA, MIN or MAX
+	Functions map[string]string `json:"functions"`
+}
+
+var summaryFunctions = []string{"SUM", "AVERAGE", "MEDIAN", "COUNT", "COUNTA", "MIN", "MAX"}
+
+var columnPlaceholder = regexp.MustCompile(`\{column:([^}]*)\}`)
+
+// AddFormulaColumns appends the formula columns after the header of the table which will be inserted at cellPosition.
+// Rows shorter than the header are padded.
+func AddFormulaColumns(table [][]string, cellPosition *CellPosition, columns []*FormulaColumn) ([][]string, error) {
+	if len(table) == 0 {
+		return table, nil
+	}
+
+	// the formulas go under their headers, so short rows are padded, and the cells of long rows past the header
+	// are moved after the formulas
+	width := len(table[0])
+	extra := make([][]string, len(table))
+	for i := 1; i < len(table); i++ {
+		for len(table[i]) < width {
+			table[i] = append(table[i], "")
+		}
+		extra[i] = table[i][width:]
+		table[i] = append([]string(nil), table[i][:width]...)
+	}
+
+	firstRow := strconv.Itoa(cellPosition.RowIndex + 1)
+	lastRow := strconv.Itoa(cellPosition.RowIndex + len(table) - 1)
+	for _, column := range columns {
+		formula, err := expandColumnPlaceholders(table[0], cellPosition, column.Formula)
+		if err != nil {
+			return nil, err
+		}
+		formula = strings.ReplaceAll(formula, "{first_row}", firstRow)
+		formula = strings.ReplaceAll(formula, "{last_row}", lastRow)
+
+		table[0] = append(table[0], column.Header)
+		for i := 1; i < len(table); i++ {
+			row := strconv.Itoa(cellPosition.RowIndex + i)
+			table[i] = append(table[i], strings.ReplaceAll(formula, "{row}", row))
+		}
+	}
+	for i := 1; i < len(table); i++ {
+		table[i] = append(table[i], extra[i]...)
+	}
+	return table, nil
+}
+
+// SummaryRow returns the footer row of formulas aggregating the data rows of the table inserted at cellPosition,
+// given its header and its height in rows, including the header
+func SummaryRow(header []string, height int, cellPosition *CellPosition, summary *Summary) ([]string, error) {
+	if len(header) == 0 {
+		return nil, nil
+	}
+
+	row := make([]string, len(header))
+	for column, function := range summary.Functions {
+		function = strings.ToUpper(function)
+		if sliceIndex(summaryFunctions, function) < 0 {
+			return nil, validationErrorf("unknown summary function: %s", function)
+		}
+		index := sliceIndex(header, column)
+		if index < 0 {
+			return nil, missingColumnError(column)
+		}
+
+		start, err := cellPosition.Offset(1, index).ToAlphaNumeric()
+		if err != nil {
+			return nil, err
+		}
+		end, err := cellPosition.Offset(height-1, index).ToAlphaNumeric()
+		if err != nil {
+			return nil, err
+		}
+		row[index] = "=" + function + "(" + start + ":" + end + ")"
+	}
+
+	if row[0] == "" {
+		row[0] = summary.Label
+		if row[0] == "" {
+			row[0] = "Total"
+		}
+	}
+	return row, nil
+}
+
+func expandColumnPlaceholders(header []string, cellPosition *CellPosition, formula string) (string, error) {
+	var err error
+	expanded := columnPlaceholder.ReplaceAllStringFunc(formula, func(placeholder string) string {
+		name := columnPlaceholder.FindStringSubmatch(placeholder)[1]
+		index := sliceIndex(header, name)
+		if index < 0 {
+			err = missingColumnError(name)
+			return placeholder
+		}
+		column, columnErr := indexToColumn(cellPosition.ColumnIndex + index)
+		if columnErr != nil {
+			err = columnErr
+		}
+		return column
+	})
+	return expanded, err
+}
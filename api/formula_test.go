@@ -0,0 +1,39 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAddFormulaColumns(t *testing.T) {
+	table := [][]string{
+		{"Name", "Current", "Previous"},
+		{"a", "3", "1"},
+		{"b"},
+		{"c", "5", "2", "extra"},
+	}
+	table, err := AddFormulaColumns(table, Origin(), []*FormulaColumn{
+		{Header: "Change", Formula: "={column:Current}{row}-{column:Previous}{row}"},
+		{Header: "Share", Formula: "=B{row}/SUM(B{first_row}:B{last_row})"},
+	})
+	if err != nil {
+		t.Fatalf("AddFormulaColumns: %s", err.Error())
+	}
+	want := [][]string{
+		{"Name", "Current", "Previous", "Change", "Share"},
+		{"a", "3", "1", "=B2-C2", "=B2/SUM(B2:B4)"},
+		{"b", "", "", "=B3-C3", "=B3/SUM(B2:B4)"},
+		{"c", "5", "2", "=B4-C4", "=B4/SUM(B2:B4)", "extra"},
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("got %q, want %q", table, want)
+	}
+
+	_, err = AddFormulaColumns([][]string{{"Name"}, {"a"}}, Origin(), []*FormulaColumn{
+		{Header: "Change", Formula: "={column:Missing}{row}"},
+	})
+	if !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("got %v, want ErrMissingColumn", err)
+	}
+}
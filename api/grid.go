@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"google.golang.org/api/sheets/v4"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of a spreadsheet: https://support.google.com/drive/answer/37603
+const (
+	maxSpreadsheetCells = 10000000
+	maxCellCharacters   = 50000
+)
+
+// getSheetsProperties returns the properties of every sheet, including their grid sizes
+func (s *Service) getSheetsProperties(spreadsheetId string) ([]*sheets.SheetProperties, error) {
+	resp, err := s.sheetsService().Spreadsheets.Get(spreadsheetId).Fields("sheets.properties").Do()
+	if err != nil {
+		return nil, s.sheetsError(err, spreadsheetId, "")
+	}
+	var sheetsProperties []*sheets.SheetProperties
+	for _, sheet := range resp.Sheets {
+		sheetsProperties = append(sheetsProperties, sheet.Properties)
+	}
+	return sheetsProperties, nil
+}
+
+// findSheetProperties returns the properties of the sheet with the given title, or of the first sheet when title is empty
+func findSheetProperties(sheetsProperties []*sheets.SheetProperties, title string) (*sheets.SheetProperties, error) {
+	for _, properties := range sheetsProperties {
+		if title == "" || properties.Title == title {
+			if properties.GridProperties == nil {
+				return nil, &Error{
+					Kind:  ErrInvalidRange,
+					Range: properties.Title,
+					Err:   errNoGrid,
+				}
+			}
+			return properties, nil
+		}
+	}
+	if title == "" {
+		return nil, &Error{
+			Kind: ErrNotFound,
+			Err:  errNoSheets,
+		}
+	}
+	return nil, &Error{
+		Kind:  ErrNotFound,
+		Range: title,
+	}
+}
+
+// ensureGridSize appends rows and columns to the sheet until it has at least rows rows and columns columns.
+// It returns an error without changing the sheet when the spreadsheet would have more cells than allowed.
+// properties are updated with the new grid size.
+func (s *Service) ensureGridSize(spreadsheetId string, sheetsProperties []*sheets.SheetProperties, properties *sheets.SheetProperties, rows int64, columns int64) error {
+	grid := properties.GridProperties
+	if rows < grid.RowCount {
+		rows = grid.RowCount
+	}
+	if columns < grid.ColumnCount {
+		columns = grid.ColumnCount
+	}
+	if rows == grid.RowCount && columns == grid.ColumnCount {
+		return nil
+	}
+
+	cells := rows * columns
+	for _, other := range sheetsProperties {
+		if other.SheetId != properties.SheetId && other.GridProperties != nil {
+			cells += other.GridProperties.RowCount * other.GridProperties.ColumnCount
+		}
+	}
+	if cells > maxSpreadsheetCells {
+		return &Error{
+			Kind:          ErrValidation,
+			SpreadsheetId: spreadsheetId,
+			Range:         properties.Title,
+			Err: fmt.Errorf("sheet %s needs %d rows and %d columns, which would make the spreadsheet %d cells, above the limit of %d",
+				properties.Title, rows, columns, cells, maxSpreadsheetCells),
+		}
+	}
+
+	var requests []*sheets.Request
+	if rows > grid.RowCount {
+		requests = append(requests, &sheets.Request{
+			AppendDimension: &sheets.AppendDimensionRequest{
+				Dimension: "ROWS",
+				Length:    rows - grid.RowCount,
+				SheetId:   properties.SheetId,
+			},
+		})
+	}
+	if columns > grid.ColumnCount {
+		requests = append(requests, &sheets.Request{
+			AppendDimension: &sheets.AppendDimensionRequest{
+				Dimension: "COLUMNS",
+				Length:    columns - grid.ColumnCount,
+				SheetId:   properties.SheetId,
+			},
+		})
+	}
+
+	_, err := s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Do()
+	if err != nil {
+		return s.sheetsError(err, spreadsheetId, "")
+	}
+	grid.RowCount = rows
+	grid.ColumnCount = columns
+	return nil
+}
+
+// checkCellLengths returns an error naming the first cell of the table, inserted at cellPosition, which is too long
+func checkCellLengths(cellPosition *CellPosition, table [][]string) error {
+	for i, row := range table {
+		for j, cell := range row {
+			if len(cell) <= maxCellCharacters {
+				continue
+			}
+			length := utf8.RuneCountInString(cell)
+			if length <= maxCellCharacters {
+				continue
+			}
+			position, err := cellPosition.Offset(i, j).ToAlphaNumeric()
+			if err != nil {
+				return err
+			}
+			return &Error{
+				Kind:  ErrValidation,
+				Range: position,
+				Err:   fmt.Errorf("cell %s has %d characters, above the limit of %d", position, length, maxCellCharacters),
+			}
+		}
+	}
+	return nil
+}
+
+// sheetRange quotes the sheet title for use in A1 notation
+func sheetRange(title string) string {
+	return "'" + strings.ReplaceAll(title, "'", "''") + "'"
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// jsonSource reads objects into rows. The header is the union of the keys of all objects,
+// in order of first appearance, so the whole input is read before the first row is returned.
+type jsonSource struct {
+	file io.ReadCloser
+	// reads the next object, or returns io.EOF after the last object
+	readObject func(decoder *json.Decoder) (json.RawMessage, error)
+	rows       [][]string
+	loaded     bool
+}
+
+// NewJsonSource reads a JSON array of objects from file, which is closed with the source
+func NewJsonSource(file io.ReadCloser) TableSource {
+	started := false
+	return &jsonSource{
+		file: file,
+		readObject: func(decoder *json.Decoder) (json.RawMessage, error) {
+			if !started {
+				started = true
+				token, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				if token != json.Delim('[') {
+					return nil, validationErrorf("expected a JSON array of objects")
+				}
+			}
+			if !decoder.More() {
+				return nil, io.EOF
+			}
+			var object json.RawMessage
+			err := decoder.Decode(&object)
+			return object, err
+		},
+	}
+}
+
+// NewJsonLinesSource reads one JSON object per line from file, which is closed with the source
+func NewJsonLinesSource(file io.ReadCloser) TableSource {
+	return &jsonSource{
+		file: file,
+		readObject: func(decoder *json.Decoder) (json.RawMessage, error) {
+			var object json.RawMessage
+			err := decoder.Decode(&object)
+			return object, err
+		},
+	}
+}
+
+func (j *jsonSource) Next() ([]string, error) {
+	if !j.loaded {
+		j.loaded = true
+		err := j.load()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(j.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := j.rows[0]
+	j.rows = j.rows[1:]
+	return row, nil
+}
+
+func (j *jsonSource) Close() error {
+	return j.file.Close()
+}
+
+func (j *jsonSource) load() error {
+	decoder := json.NewDecoder(j.file)
+	decoder.UseNumber()
+
+	var header []string
+	var objects []map[string]string
+	for {
+		raw, err := j.readObject(decoder)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		keys, object, err := decodeObject(raw)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if sliceIndex(header, key) < 0 {
+				header = append(header, key)
+			}
+		}
+		objects = append(objects, object)
+	}
+	if len(header) == 0 {
+		return nil
+	}
+
+	j.rows = [][]string{header}
+	for _, object := range objects {
+		row := make([]string, len(header))
+		for i, key := range header {
+			row[i] = object[key]
+		}
+		j.rows = append(j.rows, row)
+	}
+	return nil
+}
+
+// decodeObject returns the keys of a JSON object in order, and its values formatted as cells
+func decodeObject(raw json.RawMessage) ([]string, map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, nil, validationErrorf("expected a JSON object but got %s", string(raw))
+	}
+
+	var keys []string
+	object := make(map[string]string)
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := token.(string)
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := object[key]; !ok {
+			keys = append(keys, key)
+		}
+		object[key], err = jsonCell(value)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return keys, object, nil
+}
+
+// jsonCell formats strings and numbers as is, booleans as TRUE or FALSE, null as empty,
+// and nested arrays and objects as compact JSON
+func jsonCell(value json.RawMessage) (string, error) {
+	switch {
+	case len(value) == 0 || string(value) == "null":
+		return "", nil
+	case value[0] == '"':
+		var text string
+		err := json.Unmarshal(value, &text)
+		return text, err
+	case string(value) == "true":
+		return "TRUE", nil
+	case string(value) == "false":
+		return "FALSE", nil
+	case value[0] == '{' || value[0] == '[':
+		var compacted bytes.Buffer
+		err := json.Compact(&compacted, value)
+		return compacted.String(), err
+	default:
+		return string(value), nil
+	}
+}
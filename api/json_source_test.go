@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJsonSource(t *testing.T) {
+	// the header is the union of the keys in order of first appearance, whatever the order of later objects
+	want := [][]string{
+		{"name", "score", "active", "tags"},
+		{"alpha", "1.50", "", ""},
+		{"beta", "", "TRUE", `{"a":[1,2]}`},
+		{"gamma", "-3", "FALSE", ""},
+	}
+	tests := []struct {
+		name   string
+		source func(file io.ReadCloser) TableSource
+		input  string
+	}{
+		{
+			name:   "json",
+			source: NewJsonSource,
+			input: `[
+				{"name": "alpha", "score": 1.50},
+				{"active": true, "name": "beta", "tags": {"a": [1, 2]}},
+				{"score": -3, "active": false, "name": "gamma", "tags": null}
+			]`,
+		},
+		{
+			name:   "jsonl",
+			source: NewJsonLinesSource,
+			input: `{"name": "alpha", "score": 1.50}
+{"active": true, "name": "beta", "tags": {"a": [1, 2]}}
+{"score": -3, "active": false, "name": "gamma", "tags": null}
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table, err := ReadAll(test.source(io.NopCloser(strings.NewReader(test.input))))
+			if err != nil {
+				t.Fatalf("ReadAll: %s", err.Error())
+			}
+			if !reflect.DeepEqual(table, want) {
+				t.Errorf("got %q, want %q", table, want)
+			}
+		})
+	}
+}
+
+func TestJsonSourceErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source func(file io.ReadCloser) TableSource
+		input  string
+		want   [][]string
+		err    error
+	}{
+		{name: "empty array", source: NewJsonSource, input: `[]`},
+		{name: "empty lines", source: NewJsonLinesSource, input: ``},
+		{name: "object", source: NewJsonSource, input: `{"name": "alpha"}`, err: ErrValidation},
+		{name: "array of numbers", source: NewJsonSource, input: `[1, 2]`, err: ErrValidation},
+		{name: "line of strings", source: NewJsonLinesSource, input: `"alpha"`, err: ErrValidation},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table, err := ReadAll(test.source(io.NopCloser(strings.NewReader(test.input))))
+			if !errors.Is(err, test.err) || (test.err == nil && err != nil) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+			if !reflect.DeepEqual(table, test.want) {
+				t.Errorf("got %q, want %q", table, test.want)
+			}
+		})
+	}
+}
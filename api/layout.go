@@ -0,0 +1,183 @@
+package api
+
+import (
+	"google.golang.org/api/sheets/v4"
+)
+
+// FilterView is a named filter over a table, which viewers can toggle without affecting each other
+type FilterView struct {
+	Title      string       `json:"title"`
+	Conditions []*Predicate `json:"conditions"`
+	SortColumn string       `json:"sort_column"`
+	Descending bool         `json:"descending"`
+}
+
+// FreezePanes keeps the first rows and columns of the first sheet visible while scrolling
+func (s *Service) FreezePanes(spreadsheetId string, rows int, columns int) error {
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	request := &sheets.Request{
+		UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+			Fields: "gridProperties.frozenRowCount,gridProperties.frozenColumnCount",
+			Properties: &sheets.SheetProperties{
+				SheetId: sheetId,
+				GridProperties: &sheets.GridProperties{
+					FrozenRowCount:    int64(rows),
+					FrozenColumnCount: int64(columns),
+				},
+			},
+		},
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			request,
+		},
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+// AutoResizeColumns fits the widths of the columns from startColumn to endColumn inclusive to their contents
+func (s *Service) AutoResizeColumns(spreadsheetId string, startColumn int, endColumn int) error {
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	request := &sheets.Request{
+		AutoResizeDimensions: &sheets.AutoResizeDimensionsRequest{
+			Dimensions: &sheets.DimensionRange{
+				Dimension:  "COLUMNS",
+				SheetId:    sheetId,
+				StartIndex: int64(startColumn - 1),
+				EndIndex:   int64(endColumn),
+			},
+		},
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			request,
+		},
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+// SetColumnWidths sets the pixel width of each column, keyed by column index where column A is 1
+func (s *Service) SetColumnWidths(spreadsheetId string, widths map[int]int64) error {
+	if len(widths) == 0 {
+		return nil
+	}
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	var requests []*sheets.Request
+	for column, width := range widths {
+		requests = append(requests, &sheets.Request{
+			UpdateDimensionProperties: &sheets.UpdateDimensionPropertiesRequest{
+				Fields: "pixelSize",
+				Properties: &sheets.DimensionProperties{
+					PixelSize: width,
+				},
+				Range: &sheets.DimensionRange{
+					Dimension:  "COLUMNS",
+					SheetId:    sheetId,
+					StartIndex: int64(column - 1),
+					EndIndex:   int64(column),
+				},
+			},
+		})
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+// SetBasicFilter adds the filter buttons to the header row of the range, replacing any existing basic filter
+func (s *Service) SetBasicFilter(spreadsheetId string, startPosition *CellPosition, endPosition *CellPosition) error {
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	request := &sheets.Request{
+		SetBasicFilter: &sheets.SetBasicFilterRequest{
+			Filter: &sheets.BasicFilter{
+				Range: toGridRange(sheetId, startPosition, endPosition),
+			},
+		},
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			request,
+		},
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+// AddFilterView adds a filter view over the range. header names the columns of the range,
+// which the conditions and sort column of the filter view refer to.
+func (s *Service) AddFilterView(spreadsheetId string, startPosition *CellPosition, endPosition *CellPosition, header []string, filterView *FilterView) error {
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	view := &sheets.FilterView{
+		Range: toGridRange(sheetId, startPosition, endPosition),
+		Title: filterView.Title,
+	}
+	for _, condition := range filterView.Conditions {
+		index := sliceIndex(header, condition.Column)
+		if index < 0 {
+			return missingColumnError(condition.Column)
+		}
+		criteria, err := condition.toFilterCriteria()
+		if err != nil {
+			return err
+		}
+		view.FilterSpecs = append(view.FilterSpecs, &sheets.FilterSpec{
+			ColumnIndex:     int64(startPosition.ColumnIndex - 1 + index),
+			FilterCriteria:  criteria,
+			ForceSendFields: []string{"ColumnIndex"},
+		})
+	}
+	if filterView.SortColumn != "" {
+		index := sliceIndex(header, filterView.SortColumn)
+		if index < 0 {
+			return missingColumnError(filterView.SortColumn)
+		}
+		sortOrder := "ASCENDING"
+		if filterView.Descending {
+			sortOrder = "DESCENDING"
+		}
+		view.SortSpecs = []*sheets.SortSpec{
+			{
+				DimensionIndex:  int64(startPosition.ColumnIndex - 1 + index),
+				SortOrder:       sortOrder,
+				ForceSendFields: []string{"DimensionIndex"},
+			},
+		}
+	}
+
+	request := &sheets.Request{
+		AddFilterView: &sheets.AddFilterViewRequest{
+			Filter: view,
+		},
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			request,
+		},
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Levels of log messages, from the most verbose
+const (
+	LevelDebug = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// Logger writes leveled messages with key value fields, one per line, as text or JSON.
+// A nil Logger discards every message.
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	json  bool
+	level int
+}
+
+// NewLogger writes messages of level, one of debug, info, warn or error, and above to w in format, text or json
+func NewLogger(w io.Writer, format string, level string) (*Logger, error) {
+	logger := &Logger{
+		w:     w,
+		level: sliceIndex(levelNames, level),
+	}
+	if logger.level < 0 {
+		return nil, fmt.Errorf("unknown log level: %s", level)
+	}
+	switch format {
+	case "text":
+	case "json":
+		logger.json = true
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", format)
+	}
+	return logger, nil
+}
+
+func (l *Logger) Debug(message string, fields ...interface{}) {
+	l.log(LevelDebug, message, fields)
+}
+
+func (l *Logger) Info(message string, fields ...interface{}) {
+	l.log(LevelInfo, message, fields)
+}
+
+func (l *Logger) Warn(message string, fields ...interface{}) {
+	l.log(LevelWarn, message, fields)
+}
+
+func (l *Logger) Error(message string, fields ...interface{}) {
+	l.log(LevelError, message, fields)
+}
+
+// Enabled returns whether messages of level are written
+func (l *Logger) Enabled(level int) bool {
+	return l != nil && level >= l.level
+}
+
+// log writes the message with fields, alternating keys and values
+func (l *Logger) log(level int, message string, fields []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	all := []interface{}{"time", time.Now().Format(time.RFC3339Nano), "level", levelNames[level], "msg", message}
+	all = append(all, fields...)
+
+	var line bytes.Buffer
+	if l.json {
+		line.WriteString("{")
+	}
+	for i := 0; i+1 < len(all); i += 2 {
+		key := fmt.Sprint(all[i])
+		value := logValue(all[i+1])
+		if l.json {
+			if i > 0 {
+				line.WriteString(",")
+			}
+			keyJson, _ := json.Marshal(key)
+			valueJson, err := json.Marshal(value)
+			if err != nil {
+				valueJson, _ = json.Marshal(fmt.Sprint(value))
+			}
+			line.Write(keyJson)
+			line.WriteString(":")
+			line.Write(valueJson)
+		} else {
+			if i > 0 {
+				line.WriteString(" ")
+			}
+			line.WriteString(key)
+			line.WriteString("=")
+			line.WriteString(textValue(value))
+		}
+	}
+	if l.json {
+		line.WriteString("}")
+	}
+	line.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(line.Bytes())
+}
+
+// logValue converts errors and values with a String method, like durations, to their text
+func logValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+// textValue formats the value, quoting it when it is empty or has spaces, quotes or =
+func textValue(value interface{}) string {
+	text := fmt.Sprint(value)
+	if text == "" || strings.ContainsAny(text, " \t\n\"=") {
+		return fmt.Sprintf("%q", text)
+	}
+	return text
+}
+
+// Stats counts the HTTP requests of a Service
+type Stats struct {
+	Requests  int64 `json:"requests"`
+	BytesSent int64 `json:"bytes_sent"`
+	Retries   int64 `json:"retries"`
+}
+
+// Sub returns the counts since previous
+func (s Stats) Sub(previous Stats) Stats {
+	return Stats{
+		Requests:  s.Requests - previous.Requests,
+		BytesSent: s.BytesSent - previous.BytesSent,
+		Retries:   s.Retries - previous.Retries,
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTextValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"plain", "plain"},
+		{"", `""`},
+		{"two words", `"two words"`},
+		{"tab\there", `"tab\there"`},
+		{"line\nbreak", `"line\nbreak"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"a=b", `"a=b"`},
+		{42, "42"},
+		{1.5, "1.5"},
+		{true, "true"},
+	}
+	for _, test := range tests {
+		got := textValue(test.value)
+		if got != test.want {
+			t.Errorf("textValue(%#v) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestLoggerText(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := NewLogger(&out, "text", "info")
+	if err != nil {
+		t.Fatalf("NewLogger: %s", err.Error())
+	}
+	logger.Debug("hidden")
+	logger.Info("step done", "step", "upload table", "requests", 3, "error", errors.New("bad = value"))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1:\n%s", len(lines), out.String())
+	}
+	if !strings.HasPrefix(lines[0], "time=") {
+		t.Errorf("got %s, want the time first", lines[0])
+	}
+	want := ` level=info msg="step done" step="upload table" requests=3 error="bad = value"`
+	if !strings.HasSuffix(lines[0], want) {
+		t.Errorf("got %s, want it to end with %s", lines[0], want)
+	}
+}
+
+func TestLoggerJson(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := NewLogger(&out, "json", "warn")
+	if err != nil {
+		t.Fatalf("NewLogger: %s", err.Error())
+	}
+	logger.Info("hidden")
+	logger.Warn("retrying request", "status", 429, "delay", 2*time.Second, "path", "/v4/spreadsheets/\"id\"")
+	logger.Error("run failed", "error", errors.New("line\nbreak"), "stats", Stats{Requests: 1})
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), out.String())
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, `{"time":`) {
+			t.Errorf("got %s, want the time first", line)
+		}
+	}
+
+	var warn map[string]interface{}
+	err = json.Unmarshal([]byte(lines[0]), &warn)
+	if err != nil {
+		t.Fatalf("line is not JSON: %s", lines[0])
+	}
+	if warn["level"] != "warn" || warn["msg"] != "retrying request" || warn["status"] != float64(429) ||
+		warn["delay"] != "2s" || warn["path"] != "/v4/spreadsheets/\"id\"" {
+		t.Errorf("got %v", warn)
+	}
+
+	var failed struct {
+		Level string `json:"level"`
+		Error string `json:"error"`
+		Stats Stats  `json:"stats"`
+	}
+	err = json.Unmarshal([]byte(lines[1]), &failed)
+	if err != nil {
+		t.Fatalf("line is not JSON: %s", lines[1])
+	}
+	if failed.Level != "error" || failed.Error != "line\nbreak" || failed.Stats.Requests != 1 {
+		t.Errorf("got %+v", failed)
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	var logger *Logger
+	logger.Error("discarded", "key", "value")
+	if logger.Enabled(LevelError) {
+		t.Errorf("nil logger is enabled")
+	}
+}
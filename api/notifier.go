@@ -0,0 +1,295 @@
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// Notifier sends the report email
+type Notifier interface {
+	Notify(email *Email) error
+}
+
+// NotifierConfig selects and configures a Notifier
+type NotifierConfig struct {
+	// gmail, smtp or webhook. Defaults to gmail
+	Type string `json:"type"`
+
+	// SMTP server
+	Host string `json:"host"`
+	// Defaults to 587, or 465 with TLS
+	Port int `json:"port"`
+	// Connects with TLS, e.g. to port 465
+	TLS bool `json:"tls"`
+	// Upgrades the connection with STARTTLS, failing when the server does not support it
+	StartTLS bool   `json:"starttls"`
+	Username string `json:"username"`
+	// Environment variable holding the password, to keep it out of the config
+	PasswordEnv string `json:"password_env"`
+	// Sender of emails which have no From
+	From string `json:"from"`
+
+	// Webhook URL, e.g. of a Slack or Teams incoming webhook
+	URL string `json:"url"`
+	// text/template of the JSON payload, rendered with the email. Defaults to {"text": "<subject>\n<text>"}
+	Payload string            `json:"payload"`
+	Headers map[string]string `json:"headers"`
+
+	// Time allowed to send an email with SMTP or a webhook. Defaults to 30
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+const defaultNotifyTimeout = 30 * time.Second
+
+// NewNotifier returns the notifier of the config, sending with the service's Gmail for type gmail
+func NewNotifier(config *NotifierConfig, service *Service) (Notifier, error) {
+	timeout := defaultNotifyTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	switch config.Type {
+	case "", "gmail":
+		return &GmailNotifier{Service: service}, nil
+	case "smtp":
+		if config.Host == "" {
+			return nil, validationErrorf("smtp notifier has no host")
+		}
+		if config.From == "" {
+			return nil, validationErrorf("smtp notifier has no from")
+		}
+		password := ""
+		if config.PasswordEnv != "" {
+			password = os.Getenv(config.PasswordEnv)
+		}
+		return &SmtpNotifier{
+			Host:     config.Host,
+			Port:     config.Port,
+			TLS:      config.TLS,
+			StartTLS: config.StartTLS,
+			Username: config.Username,
+			Password: password,
+			From:     config.From,
+			Timeout:  timeout,
+		}, nil
+	case "webhook":
+		notifier, err := NewWebhookNotifier(config.URL, config.Payload, config.Headers)
+		if err != nil {
+			return nil, err
+		}
+		notifier.Client.Timeout = timeout
+		return notifier, nil
+	default:
+		return nil, validationErrorf("unknown notifier type: %s", config.Type)
+	}
+}
+
+// GmailNotifier sends emails with the Gmail API as the authenticated user
+type GmailNotifier struct {
+	Service *Service
+}
+
+func (n *GmailNotifier) Notify(email *Email) error {
+	return n.Service.Send(email)
+}
+
+// SmtpNotifier sends emails to an SMTP server
+type SmtpNotifier struct {
+	Host     string
+	Port     int
+	TLS      bool
+	StartTLS bool
+	// Authenticates with PLAIN when set. Go refuses PLAIN without TLS except to localhost
+	Username string
+	Password string
+	// Sender of emails which have no From
+	From string
+	// Time allowed to connect and send the email. Defaults to 30 seconds
+	Timeout time.Duration
+	// Replaces the TLS configuration verifying the server, e.g. to trust a private CA
+	TLSConfig *tls.Config
+}
+
+func (n *SmtpNotifier) Notify(email *Email) error {
+	if email.From == "" {
+		copied := *email
+		copied.From = n.From
+		email = &copied
+	}
+	if email.From == "" {
+		return validationErrorf("smtp email has no sender")
+	}
+	from, err := mail.ParseAddress(email.From)
+	if err != nil {
+		return validationErrorf("invalid From address %s: %w", email.From, err)
+	}
+	message, err := email.message(false)
+	if err != nil {
+		return err
+	}
+
+	port := n.Port
+	if port == 0 {
+		port = 587
+		if n.TLS {
+			port = 465
+		}
+	}
+	address := net.JoinHostPort(n.Host, strconv.Itoa(port))
+	tlsConfig := n.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: n.Host}
+	}
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = defaultNotifyTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var connection net.Conn
+	if n.TLS {
+		connection, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		connection, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	// a server which stops responding fails the email instead of hanging
+	err = connection.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		connection.Close()
+		return err
+	}
+	client, err := smtp.NewClient(connection, n.Host)
+	if err != nil {
+		connection.Close()
+		return err
+	}
+	defer client.Close()
+
+	if n.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", n.Host)
+		}
+		err = client.StartTLS(tlsConfig)
+		if err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(from.Address)
+	if err != nil {
+		return err
+	}
+	for _, recipients := range [][]string{email.To, email.Cc, email.Bcc} {
+		for _, recipient := range recipients {
+			to, err := mail.ParseAddress(recipient)
+			if err != nil {
+				return validationErrorf("invalid address %s: %w", recipient, err)
+			}
+			err = client.Rcpt(to.Address)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(message)
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// WebhookNotifier posts a JSON payload rendered from the email to a URL
+type WebhookNotifier struct {
+	URL     string
+	Payload *template.Template
+	Headers map[string]string
+	Client  *http.Client
+}
+
+var webhookFuncs = template.FuncMap{
+	// json quotes a value for use in the JSON payload
+	"json": func(value interface{}) (string, error) {
+		b, err := json.Marshal(value)
+		return string(b), err
+	},
+}
+
+const defaultWebhookPayload = `{"text": {{json (printf "%s\n%s" .Subject .Text)}}}`
+
+// NewWebhookNotifier parses the text/template of the JSON payload, or uses {"text": "<subject>\n<text>"} when it is empty.
+// The template has the fields of Email and the function json, which quotes a value.
+func NewWebhookNotifier(url string, payload string, headers map[string]string) (*WebhookNotifier, error) {
+	if url == "" {
+		return nil, validationErrorf("webhook has no url")
+	}
+	if payload == "" {
+		payload = defaultWebhookPayload
+	}
+	payloadTemplate, err := template.New("payload").Funcs(webhookFuncs).Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookNotifier{
+		URL:     url,
+		Payload: payloadTemplate,
+		Headers: headers,
+		Client:  &http.Client{Timeout: defaultNotifyTimeout},
+	}, nil
+}
+
+func (n *WebhookNotifier) Notify(email *Email) error {
+	var payload bytes.Buffer
+	err := n.Payload.Execute(&payload, email)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(payload.Bytes()) {
+		return validationErrorf("webhook payload is not JSON: %s", payload.String())
+	}
+
+	request, err := http.NewRequest(http.MethodPost, n.URL, &payload)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range n.Headers {
+		request.Header.Set(name, value)
+	}
+	resp, err := n.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, body)
+	}
+	return nil
+}
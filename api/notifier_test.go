@@ -0,0 +1,374 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEmail() *Email {
+	return &Email{
+		To:      []string{"to@example.com"},
+		Bcc:     []string{"hidden@example.com"},
+		Subject: "Weekly report",
+		Text:    "Document link: https://example.com\n\"quoted\"",
+	}
+}
+
+func TestWebhookPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    map[string]interface{}
+	}{
+		{
+			name: "default",
+			want: map[string]interface{}{
+				"text": "Weekly report\nDocument link: https://example.com\n\"quoted\"",
+			},
+		},
+		{
+			name:    "slack blocks",
+			payload: `{"text": {{json .Subject}}, "blocks": [{"type": "section", "text": {"type": "mrkdwn", "text": {{json .Text}}}}]}`,
+			want: map[string]interface{}{
+				"text": "Weekly report",
+				"blocks": []interface{}{
+					map[string]interface{}{
+						"type": "section",
+						"text": map[string]interface{}{
+							"type": "mrkdwn",
+							"text": "Document link: https://example.com\n\"quoted\"",
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got map[string]interface{}
+			var contentType, token string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType = r.Header.Get("Content-Type")
+				token = r.Header.Get("X-Token")
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Errorf("payload is not JSON: %s", body)
+				}
+			}))
+			defer server.Close()
+
+			notifier, err := NewNotifier(&NotifierConfig{
+				Type:    "webhook",
+				URL:     server.URL,
+				Payload: test.payload,
+				Headers: map[string]string{"X-Token": "secret"},
+			}, nil)
+			if err != nil {
+				t.Fatalf("NewNotifier: %s", err.Error())
+			}
+			err = notifier.Notify(testEmail())
+			if err != nil {
+				t.Fatalf("Notify: %s", err.Error())
+			}
+			if contentType != "application/json" || token != "secret" {
+				t.Errorf("got Content-Type %q and X-Token %q", contentType, token)
+			}
+			gotJson, _ := json.Marshal(got)
+			wantJson, _ := json.Marshal(test.want)
+			if string(gotJson) != string(wantJson) {
+				t.Errorf("got %s, want %s", gotJson, wantJson)
+			}
+		})
+	}
+}
+
+func TestWebhookErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+	notifier, err := NewWebhookNotifier(server.URL, "", nil)
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier: %s", err.Error())
+	}
+	err = notifier.Notify(testEmail())
+	if err == nil || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("got %v, want the response of the webhook", err)
+	}
+
+	notifier, err = NewWebhookNotifier(server.URL, `{"text": {{.Subject}}}`, nil)
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier: %s", err.Error())
+	}
+	err = notifier.Notify(testEmail())
+	if err == nil || !strings.Contains(err.Error(), "not JSON") {
+		t.Errorf("got %v, want an invalid payload error", err)
+	}
+
+	_, err = NewNotifier(&NotifierConfig{Type: "webhook"}, nil)
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("got %v, want ErrValidation for a missing url", err)
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	notifier, err := NewWebhookNotifier(server.URL, "", nil)
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier: %s", err.Error())
+	}
+	notifier.Client.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	err = notifier.Notify(testEmail())
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("got %v after %s, want a timeout", err, time.Since(start))
+	}
+}
+
+// smtpServer is a stand-in SMTP server accepting one email, optionally after STARTTLS and PLAIN auth
+type smtpServer struct {
+	listener net.Listener
+	tls      *tls.Config
+	startTLS bool
+
+	auth       string
+	from       string
+	recipients []string
+	data       string
+	upgraded   bool
+	done       chan error
+}
+
+func newSmtpServer(t *testing.T, startTLS bool) (*smtpServer, *x509.CertPool) {
+	certificate, pool := testCertificate(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err.Error())
+	}
+	server := &smtpServer{
+		listener: listener,
+		tls:      &tls.Config{Certificates: []tls.Certificate{certificate}},
+		startTLS: startTLS,
+		done:     make(chan error, 1),
+	}
+	go func() {
+		server.done <- server.serve()
+	}()
+	return server, pool
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() error {
+	connection, err := s.listener.Accept()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+	text := textproto.NewConn(connection)
+	text.PrintfLine("220 stand-in ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return err
+		}
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			if s.startTLS && !s.upgraded {
+				text.PrintfLine("250-stand-in\r\n250 STARTTLS")
+			} else {
+				text.PrintfLine("250-stand-in\r\n250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			text.PrintfLine("220 ready")
+			tlsConnection := tls.Server(connection, s.tls)
+			err = tlsConnection.Handshake()
+			if err != nil {
+				return err
+			}
+			s.upgraded = true
+			text = textproto.NewConn(tlsConnection)
+		case "AUTH":
+			_, initial, _ := strings.Cut(argument, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(initial)
+			s.auth = string(decoded)
+			text.PrintfLine("235 accepted")
+		case "MAIL":
+			s.from = argument
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			s.recipients = append(s.recipients, argument)
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return err
+			}
+			s.data = string(data)
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return nil
+		default:
+			text.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+// testCertificate returns a self-signed certificate for 127.0.0.1 and a pool trusting it
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err.Error())
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err.Error())
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestSmtpStartTLSAuth(t *testing.T) {
+	server, pool := newSmtpServer(t, true)
+	defer server.listener.Close()
+	t.Setenv("TEST_SMTP_PASSWORD", "hunter2")
+
+	notifier, err := NewNotifier(&NotifierConfig{
+		Type:        "smtp",
+		Host:        "127.0.0.1",
+		Port:        server.port(),
+		StartTLS:    true,
+		Username:    "reports",
+		PasswordEnv: "TEST_SMTP_PASSWORD",
+		From:        "Reports <reports@example.com>",
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewNotifier: %s", err.Error())
+	}
+	smtpNotifier := notifier.(*SmtpNotifier)
+	smtpNotifier.TLSConfig = &tls.Config{ServerName: "127.0.0.1", RootCAs: pool}
+
+	err = notifier.Notify(testEmail())
+	if err != nil {
+		t.Fatalf("Notify: %s", err.Error())
+	}
+	err = <-server.done
+	if err != nil {
+		t.Fatalf("server: %s", err.Error())
+	}
+
+	if !server.upgraded {
+		t.Errorf("connection was not upgraded with STARTTLS")
+	}
+	if server.auth != "\x00reports\x00hunter2" {
+		t.Errorf("got PLAIN auth %q", server.auth)
+	}
+	if server.from != "FROM:<reports@example.com>" {
+		t.Errorf("got MAIL %q", server.from)
+	}
+	wantRecipients := []string{"TO:<to@example.com>", "TO:<hidden@example.com>"}
+	if strings.Join(server.recipients, ",") != strings.Join(wantRecipients, ",") {
+		t.Errorf("got RCPT %q, want %q", server.recipients, wantRecipients)
+	}
+	if !strings.Contains(server.data, "Subject: Weekly report") || strings.Contains(server.data, "hidden@example.com") {
+		t.Errorf("got message without the subject or with the Bcc header:\n%s", server.data)
+	}
+}
+
+func TestSmtpStartTLSUnsupported(t *testing.T) {
+	server, _ := newSmtpServer(t, false)
+	defer server.listener.Close()
+
+	notifier := &SmtpNotifier{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		StartTLS: true,
+		From:     "reports@example.com",
+	}
+	err := notifier.Notify(testEmail())
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("got %v, want an error for the missing STARTTLS", err)
+	}
+}
+
+func TestSmtpTimeout(t *testing.T) {
+	// a server which accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err.Error())
+	}
+	defer listener.Close()
+	go func() {
+		connection, err := listener.Accept()
+		if err == nil {
+			defer connection.Close()
+			io.Copy(io.Discard, connection)
+		}
+	}()
+
+	notifier := &SmtpNotifier{
+		Host:    "127.0.0.1",
+		Port:    listener.Addr().(*net.TCPAddr).Port,
+		From:    "reports@example.com",
+		Timeout: 100 * time.Millisecond,
+	}
+	start := time.Now()
+	err = notifier.Notify(testEmail())
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("got %v after %s, want a timeout", err, time.Since(start))
+	}
+}
+
+func TestNewNotifierChecksSmtpConfig(t *testing.T) {
+	for _, config := range []*NotifierConfig{
+		{Type: "smtp", From: "reports@example.com"},
+		{Type: "smtp", Host: "smtp.example.com"},
+	} {
+		_, err := NewNotifier(config, nil)
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("NewNotifier(%+v): got %v, want ErrValidation", config, err)
+		}
+	}
+}
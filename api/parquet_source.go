@@ -0,0 +1,182 @@
+package api
+
+import (
+	"fmt"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/types"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Number of rows read from each column at a time
+const parquetBatchSize = 1000
+
+type parquetSource struct {
+	file     source.ParquetFile
+	reader   *reader.ParquetReader
+	elements []*parquet.SchemaElement
+	header   []string
+	rows     [][]string
+	read     int64
+}
+
+// NewParquetSource reads the rows of a parquet file with a flat schema, i.e. without nested or repeated columns.
+// The file is closed with the source.
+func NewParquetSource(file source.ParquetFile) (TableSource, error) {
+	parquetReader, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	p := &parquetSource{
+		file:   file,
+		reader: parquetReader,
+	}
+	schema := parquetReader.SchemaHandler
+	for _, path := range schema.ValueColumns {
+		index := schema.MapIndex[path]
+		p.elements = append(p.elements, schema.SchemaElements[index])
+		p.header = append(p.header, schema.GetExName(int(index)))
+	}
+	return p, nil
+}
+
+func (p *parquetSource) Next() ([]string, error) {
+	if p.header != nil {
+		header := p.header
+		p.header = nil
+		return header, nil
+	}
+
+	if len(p.rows) == 0 {
+		err := p.readBatch()
+		if err != nil {
+			return nil, err
+		}
+	}
+	row := p.rows[0]
+	p.rows = p.rows[1:]
+	return row, nil
+}
+
+func (p *parquetSource) Close() error {
+	p.reader.ReadStop()
+	return p.file.Close()
+}
+
+func (p *parquetSource) readBatch() error {
+	count := p.reader.GetNumRows() - p.read
+	if count <= 0 {
+		return io.EOF
+	}
+	if count > parquetBatchSize {
+		count = parquetBatchSize
+	}
+
+	p.rows = make([][]string, count)
+	for i := range p.rows {
+		p.rows[i] = make([]string, len(p.elements))
+	}
+	for column, element := range p.elements {
+		values, repetitionLevels, _, err := p.reader.ReadColumnByIndex(int64(column), count)
+		if err != nil {
+			return err
+		}
+		if int64(len(values)) != count {
+			return validationErrorf("parquet column %s is nested or repeated", element.GetName())
+		}
+		for i, value := range values {
+			if repetitionLevels[i] != 0 {
+				return validationErrorf("parquet column %s is nested or repeated", element.GetName())
+			}
+			p.rows[i][column] = parquetCell(value, element)
+		}
+	}
+	p.read += count
+	return nil
+}
+
+// parquetCell formats dates and timestamps so that sheets recognises them, and decimals with their scale
+func parquetCell(value interface{}, element *parquet.SchemaElement) string {
+	if value == nil {
+		return ""
+	}
+
+	if element.IsSetConvertedType() {
+		switch element.GetConvertedType() {
+		case parquet.ConvertedType_DATE:
+			if days, ok := value.(int32); ok {
+				return time.Unix(int64(days)*24*60*60, 0).UTC().Format("2006-01-02")
+			}
+		case parquet.ConvertedType_TIMESTAMP_MILLIS:
+			if millis, ok := value.(int64); ok {
+				return types.TIMESTAMP_MILLISToTime(millis, true).Format("2006-01-02 15:04:05")
+			}
+		case parquet.ConvertedType_TIMESTAMP_MICROS:
+			if micros, ok := value.(int64); ok {
+				return types.TIMESTAMP_MICROSToTime(micros, true).Format("2006-01-02 15:04:05")
+			}
+		case parquet.ConvertedType_DECIMAL:
+			scale := int(element.GetScale())
+			switch decimal := value.(type) {
+			case int32:
+				return decimalString(big.NewInt(int64(decimal)), scale)
+			case int64:
+				return decimalString(big.NewInt(decimal), scale)
+			case string:
+				// big endian two's complement
+				unscaled := new(big.Int).SetBytes([]byte(decimal))
+				if len(decimal) > 0 && decimal[0]&0x80 != 0 {
+					unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(decimal))*8))
+				}
+				return decimalString(unscaled, scale)
+			}
+		}
+	}
+	if element.GetType() == parquet.Type_INT96 {
+		if int96, ok := value.(string); ok {
+			return types.INT96ToTime(int96).Format("2006-01-02 15:04:05")
+		}
+	}
+
+	switch typed := value.(type) {
+	case bool:
+		if typed {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int32:
+		return strconv.FormatInt(int64(typed), 10)
+	case int64:
+		return strconv.FormatInt(typed, 10)
+	case float32:
+		return strconv.FormatFloat(float64(typed), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case string:
+		return typed
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+// decimalString formats an unscaled decimal with scale digits after the point
+func decimalString(unscaled *big.Int, scale int) string {
+	digits := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/writer"
+	"reflect"
+	"testing"
+)
+
+type testParquetRow struct {
+	Zeta   string   `parquet:"name=zeta, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Alpha  int64    `parquet:"name=alpha, type=INT64"`
+	Day    int32    `parquet:"name=day, type=INT32, convertedtype=DATE"`
+	Price  int64    `parquet:"name=price, type=INT64, convertedtype=DECIMAL, scale=2, precision=10"`
+	Active bool     `parquet:"name=active, type=BOOLEAN"`
+	Ratio  *float64 `parquet:"name=ratio, type=DOUBLE, repetitiontype=OPTIONAL"`
+	Amount string   `parquet:"name=amount, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=3, precision=20"`
+}
+
+func TestParquetSource(t *testing.T) {
+	ratio := 0.25
+	rows := []testParquetRow{
+		{Zeta: "alpha", Alpha: 1, Day: 19727, Price: 1205, Active: true, Ratio: &ratio, Amount: "\x05"},
+		{Zeta: "beta", Alpha: -2, Day: 0, Price: -5, Active: false, Amount: "\xfb\x2e"},
+	}
+
+	file := buffer.NewBufferFile()
+	parquetWriter, err := writer.NewParquetWriter(file, new(testParquetRow), 1)
+	if err != nil {
+		t.Fatalf("NewParquetWriter: %s", err.Error())
+	}
+	for _, row := range rows {
+		if err = parquetWriter.Write(row); err != nil {
+			t.Fatalf("Write: %s", err.Error())
+		}
+	}
+	if err = parquetWriter.WriteStop(); err != nil {
+		t.Fatalf("WriteStop: %s", err.Error())
+	}
+
+	source, err := NewParquetSource(buffer.NewBufferFileFromBytes(file.Bytes()))
+	if err != nil {
+		t.Fatalf("NewParquetSource: %s", err.Error())
+	}
+	table, err := ReadAll(source)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err.Error())
+	}
+	// columns are in the order of the schema, not sorted by name
+	want := [][]string{
+		{"zeta", "alpha", "day", "price", "active", "ratio", "amount"},
+		// decimals keep the zeros after the point, and byte array decimals are signed
+		{"alpha", "1", "2024-01-05", "12.05", "TRUE", "0.25", "0.005"},
+		{"beta", "-2", "1970-01-01", "-0.05", "FALSE", "", "-1.234"},
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("got %q, want %q", table, want)
+	}
+}
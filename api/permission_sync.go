@@ -0,0 +1,135 @@
+package api
+
+import (
+	"fmt"
+	"google.golang.org/api/drive/v3"
+	"strings"
+)
+
+// PermissionChange is a step of the plan which syncs the permissions of a file with the desired permissions
+type PermissionChange struct {
+	// add, update or remove
+	Action string
+	// The desired permission for add and update, the current permission for remove
+	Permission *Permission
+	// Id and role of the current permission for update
+	Id           string
+	PreviousRole string
+}
+
+func (c *PermissionChange) String() string {
+	target := c.Permission.Type
+	if c.Permission.EmailAddress != "" {
+		target += " " + c.Permission.EmailAddress
+	}
+	if c.Permission.Domain != "" {
+		target += " " + c.Permission.Domain
+	}
+	switch c.Action {
+	case "update":
+		return fmt.Sprintf("update %s from %s to %s", target, c.PreviousRole, c.Permission.Role)
+	case "remove":
+		return fmt.Sprintf("remove %s %s", target, c.Permission.Role)
+	default:
+		return fmt.Sprintf("add %s as %s", target, c.Permission.Role)
+	}
+}
+
+// PlanPermissions returns the changes turning the current permissions into the desired permissions.
+// Permissions are matched by type and email or domain. Owners, the user with email self, which is the account
+// making the changes, and inherited permissions are never removed or changed. A desired role other than the one of
+// an inherited permission is added to the file.
+func PlanPermissions(current []*Permission, desired []*Permission, self string) []*PermissionChange {
+	currentByKey := make(map[string]*Permission)
+	for _, permission := range current {
+		key := permission.key()
+		// a direct permission of the file takes the place of the inherited one
+		if existing, ok := currentByKey[key]; ok && !existing.Inherited {
+			continue
+		}
+		currentByKey[key] = permission
+	}
+	kept := func(permission *Permission) bool {
+		return permission.Role == "owner" || permission.Inherited ||
+			(permission.Type == "user" && self != "" && strings.EqualFold(permission.EmailAddress, self))
+	}
+
+	var plan []*PermissionChange
+	desiredKeys := make(map[string]bool)
+	for _, permission := range desired {
+		permission = permission.withDefaults()
+		key := permission.key()
+		if desiredKeys[key] {
+			continue
+		}
+		desiredKeys[key] = true
+
+		existing, ok := currentByKey[key]
+		switch {
+		case !ok || (existing.Inherited && existing.Role != permission.Role):
+			plan = append(plan, &PermissionChange{
+				Action:     "add",
+				Permission: permission,
+			})
+		case existing.Role != permission.Role && !kept(existing):
+			plan = append(plan, &PermissionChange{
+				Action:       "update",
+				Permission:   permission,
+				Id:           existing.Id,
+				PreviousRole: existing.Role,
+			})
+		}
+	}
+
+	for _, permission := range current {
+		if desiredKeys[permission.key()] || kept(permission) {
+			continue
+		}
+		plan = append(plan, &PermissionChange{
+			Action:     "remove",
+			Permission: permission,
+			Id:         permission.Id,
+		})
+	}
+	return plan
+}
+
+// ApplyPermissionPlan makes the changes of the plan to the permissions of the file
+func (s *Service) ApplyPermissionPlan(fileId string, plan []*PermissionChange, options *ShareOptions) error {
+	for _, change := range plan {
+		var err error
+		switch change.Action {
+		case "add":
+			err = s.SharePermission(fileId, change.Permission, options)
+		case "update":
+			err = s.UpdatePermission(fileId, change.Id, change.Permission.Role)
+		case "remove":
+			err = s.Revoke(fileId, change.Id)
+		default:
+			err = fmt.Errorf("unknown action: %s", change.Action)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s: %w", change, err)
+		}
+	}
+	return nil
+}
+
+// UpdatePermission changes the role of the permission with the id returned by ListPermissions
+func (s *Service) UpdatePermission(fileId string, permissionId string, role string) error {
+	if sliceIndex(permissionRoles, role) < 0 {
+		return validationErrorf("unknown permission role: %s", role)
+	}
+	call := s.driveService().Permissions.Update(fileId, permissionId, &drive.Permission{
+		Role: role,
+	}).SupportsAllDrives(true)
+	if role == "owner" {
+		call = call.TransferOwnership(true)
+	}
+	_, err := call.Do()
+	return s.driveError(err, fileId)
+}
+
+func (p *Permission) key() string {
+	return p.Type + ":" + strings.ToLower(p.EmailAddress) + ":" + strings.ToLower(p.Domain)
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanPermissions(t *testing.T) {
+	current := []*Permission{
+		{Id: "1", Type: "user", Role: "owner", EmailAddress: "owner@x.com"},
+		{Id: "2", Type: "user", Role: "writer", EmailAddress: "Robot@x.com"},
+		{Id: "3", Type: "user", Role: "reader", EmailAddress: "left@x.com"},
+		{Id: "4", Type: "group", Role: "reader", EmailAddress: "team@x.com", Inherited: true},
+		{Id: "5", Type: "domain", Role: "reader", Domain: "x.com", Inherited: true},
+		{Id: "6", Type: "user", Role: "reader", EmailAddress: "stay@x.com"},
+	}
+	desired := []*Permission{
+		{Type: "user", Role: "writer", EmailAddress: "stay@x.com"},
+		{Type: "user", Role: "reader", EmailAddress: "new@x.com"},
+		{Type: "group", Role: "writer", EmailAddress: "team@x.com"},
+	}
+
+	var got []string
+	for _, change := range PlanPermissions(current, desired, "robot@x.com") {
+		got = append(got, change.String())
+	}
+	want := []string{
+		"update user stay@x.com from reader to writer",
+		"add user new@x.com as reader",
+		"add group team@x.com as writer",
+		"remove user left@x.com reader",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
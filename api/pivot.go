@@ -0,0 +1,163 @@
+package api
+
+import (
+	"google.golang.org/api/sheets/v4"
+	"strings"
+)
+
+// PivotTable summarizes the uploaded table on another tab. Columns are referenced by header name.
+type PivotTable struct {
+	// Tab the pivot table is written to, added when missing. Defaults to Pivot
+	Sheet string `json:"sheet"`
+	// Top left cell of the pivot table in A1 notation. Defaults to A1
+	Anchor  string         `json:"anchor"`
+	Rows    []*PivotGroup  `json:"rows"`
+	Columns []*PivotGroup  `json:"columns"`
+	Values  []*PivotValue  `json:"values"`
+	Filters []*PivotFilter `json:"filters"`
+}
+
+// PivotGroup groups the rows or columns of a pivot table by the values of a source column
+type PivotGroup struct {
+	Column     string `json:"column"`
+	Descending bool   `json:"descending"`
+	HideTotals bool   `json:"hide_totals"`
+}
+
+// PivotValue aggregates a source column with one of SUM, COUNTA, COUNT, COUNTUNIQUE, AVERAGE,
+// MAX, MIN, MEDIAN, PRODUCT, STDEV, STDEVP, VAR or VARP, or computes Formula when Function is CUSTOM
+type PivotValue struct {
+	Column   string `json:"column"`
+	Function string `json:"function"`
+	Formula  string `json:"formula"`
+	Name     string `json:"name"`
+}
+
+// PivotFilter includes only the source rows whose Column has one of Values,
+// and which satisfy the predicate when an operator is given
+type PivotFilter struct {
+	Predicate
+	Values []string `json:"values"`
+}
+
+// AddPivotTable adds a pivot table over the range of the first sheet, whose first row is header
+func (s *Service) AddPivotTable(spreadsheetId string, startPosition *CellPosition, endPosition *CellPosition, header []string, pivotTable *PivotTable) error {
+	sourceSheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	title := pivotTable.Sheet
+	if title == "" {
+		title = "Pivot"
+	}
+	anchor := Origin()
+	if pivotTable.Anchor != "" {
+		anchor, err = FromAlphaNumric(pivotTable.Anchor)
+		if err != nil {
+			return err
+		}
+	}
+
+	pivot := &sheets.PivotTable{
+		Source: toGridRange(sourceSheetId, startPosition, endPosition),
+	}
+	pivot.Rows, err = toPivotGroups(header, pivotTable.Rows)
+	if err != nil {
+		return err
+	}
+	pivot.Columns, err = toPivotGroups(header, pivotTable.Columns)
+	if err != nil {
+		return err
+	}
+	for _, value := range pivotTable.Values {
+		pivotValue := &sheets.PivotValue{
+			Name:              value.Name,
+			SummarizeFunction: strings.ToUpper(value.Function),
+		}
+		if pivotValue.SummarizeFunction == "CUSTOM" {
+			pivotValue.Formula = value.Formula
+		} else {
+			index := sliceIndex(header, value.Column)
+			if index < 0 {
+				return missingColumnError(value.Column)
+			}
+			pivotValue.SourceColumnOffset = int64(index)
+			pivotValue.ForceSendFields = []string{"SourceColumnOffset"}
+		}
+		pivot.Values = append(pivot.Values, pivotValue)
+	}
+	for _, filter := range pivotTable.Filters {
+		index := sliceIndex(header, filter.Column)
+		if index < 0 {
+			return missingColumnError(filter.Column)
+		}
+		criteria := &sheets.PivotFilterCriteria{
+			VisibleValues: filter.Values,
+		}
+		if filter.Operator != "" {
+			criteria.Condition, err = filter.toBooleanCondition()
+			if err != nil {
+				return err
+			}
+		}
+		pivot.FilterSpecs = append(pivot.FilterSpecs, &sheets.PivotFilterSpec{
+			ColumnOffsetIndex: int64(index),
+			FilterCriteria:    criteria,
+			ForceSendFields:   []string{"ColumnOffsetIndex"},
+		})
+	}
+
+	sheetId, err := s.FindOrAddSheet(spreadsheetId, title)
+	if err != nil {
+		return err
+	}
+
+	request := &sheets.Request{
+		UpdateCells: &sheets.UpdateCellsRequest{
+			Fields: "pivotTable",
+			Rows: []*sheets.RowData{
+				{
+					Values: []*sheets.CellData{
+						{
+							PivotTable: pivot,
+						},
+					},
+				},
+			},
+			Start: &sheets.GridCoordinate{
+				ColumnIndex: int64(anchor.ColumnIndex - 1),
+				RowIndex:    int64(anchor.RowIndex - 1),
+				SheetId:     sheetId,
+			},
+		},
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			request,
+		},
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+func toPivotGroups(header []string, groups []*PivotGroup) ([]*sheets.PivotGroup, error) {
+	var pivotGroups []*sheets.PivotGroup
+	for _, group := range groups {
+		index := sliceIndex(header, group.Column)
+		if index < 0 {
+			return nil, missingColumnError(group.Column)
+		}
+		sortOrder := "ASCENDING"
+		if group.Descending {
+			sortOrder = "DESCENDING"
+		}
+		pivotGroups = append(pivotGroups, &sheets.PivotGroup{
+			ShowTotals:         !group.HideTotals,
+			SortOrder:          sortOrder,
+			SourceColumnOffset: int64(index),
+			ForceSendFields:    []string{"SourceColumnOffset"},
+		})
+	}
+	return pivotGroups, nil
+}
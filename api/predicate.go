@@ -0,0 +1,218 @@
+package api
+
+import (
+	"google.golang.org/api/sheets/v4"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Operators in the order they are matched when parsing, so that two character
+// operators take precedence over their one character prefixes
+var predicateOperators = []string{"==", "!=", ">=", "<=", "~=", ">", "<"}
+
+// Predicate matches the cells of a column against a value.
+// Operator is one of ==, !=, >, >=, <, <= or ~= (contains).
+// Values which parse as numbers are compared numerically. A trailing % divides the number by 100, as in Sheets,
+// so 10% matches both 10% and 0.1.
+type Predicate struct {
+	Column   string `json:"column"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// ParsePredicate parses expressions such as "status==FAIL" or "regression>10%"
+func ParsePredicate(expression string) (*Predicate, error) {
+	for i := range expression {
+		for _, operator := range predicateOperators {
+			if strings.HasPrefix(expression[i:], operator) {
+				predicate := &Predicate{
+					Column:   strings.TrimSpace(expression[:i]),
+					Operator: operator,
+					Value:    strings.TrimSpace(expression[i+len(operator):]),
+				}
+				return predicate, predicate.validate()
+			}
+		}
+	}
+	return nil, validationErrorf("missing operator in predicate: %s", expression)
+}
+
+func (p *Predicate) validate() error {
+	if p.Column == "" {
+		return validationErrorf("missing column in predicate")
+	}
+	switch p.Operator {
+	case "==", "!=", "~=":
+		return nil
+	case ">", ">=", "<", "<=":
+		if _, ok := parseNumber(p.Value); !ok {
+			return validationErrorf("predicate value %s is not a number", p.Value)
+		}
+		return nil
+	default:
+		return validationErrorf("unknown predicate operator: %s", p.Operator)
+	}
+}
+
+// Match reports whether value satisfies the predicate
+func (p *Predicate) Match(value string) (bool, error) {
+	err := p.validate()
+	if err != nil {
+		return false, err
+	}
+
+	number, isNumber := parseNumber(value)
+	expected, isExpectedNumber := parseNumber(p.Value)
+	numeric := isNumber && isExpectedNumber
+
+	switch p.Operator {
+	case "==":
+		if numeric {
+			return number == expected, nil
+		}
+		return value == p.Value, nil
+	case "!=":
+		if numeric {
+			return number != expected, nil
+		}
+		return value != p.Value, nil
+	case "~=":
+		return strings.Contains(value, p.Value), nil
+	}
+
+	// non-numeric cells never satisfy an ordering
+	if !isNumber {
+		return false, nil
+	}
+	switch p.Operator {
+	case ">":
+		return number > expected, nil
+	case ">=":
+		return number >= expected, nil
+	case "<":
+		return number < expected, nil
+	default:
+		return number <= expected, nil
+	}
+}
+
+// MatchingRows returns the indices of the rows below the header row of table which satisfy the predicate
+func (p *Predicate) MatchingRows(table [][]string) ([]int, error) {
+	if len(table) == 0 {
+		return nil, nil
+	}
+	index := sliceIndex(table[0], p.Column)
+	if index < 0 {
+		return nil, missingColumnError(p.Column)
+	}
+
+	var rows []int
+	for i := 1; i < len(table); i++ {
+		if index >= len(table[i]) {
+			continue
+		}
+		match, err := p.Match(table[i][index])
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			rows = append(rows, i)
+		}
+	}
+	return rows, nil
+}
+
+// toFilterCriteria converts the predicate to the equivalent criteria of a sheets filter
+func (p *Predicate) toFilterCriteria() (*sheets.FilterCriteria, error) {
+	err := p.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, numeric := parseNumber(p.Value); p.Operator == "!=" && !numeric {
+		return &sheets.FilterCriteria{
+			HiddenValues: []string{p.Value},
+		}, nil
+	}
+
+	condition, err := p.toBooleanCondition()
+	if err != nil {
+		return nil, err
+	}
+	return &sheets.FilterCriteria{
+		Condition: condition,
+	}, nil
+}
+
+// toBooleanCondition converts the predicate to the equivalent sheets condition.
+// Sheets has no condition for text which is not equal to a value.
+func (p *Predicate) toBooleanCondition() (*sheets.BooleanCondition, error) {
+	err := p.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	expected, numeric := parseNumber(p.Value)
+	var conditionType string
+	switch p.Operator {
+	case "==":
+		conditionType = "TEXT_EQ"
+		if numeric {
+			conditionType = "NUMBER_EQ"
+		}
+	case "!=":
+		if !numeric {
+			return nil, validationErrorf("unsupported condition %s != %s on text", p.Column, p.Value)
+		}
+		conditionType = "NUMBER_NOT_EQ"
+	case "~=":
+		conditionType = "TEXT_CONTAINS"
+	case ">":
+		conditionType = "NUMBER_GREATER"
+	case ">=":
+		conditionType = "NUMBER_GREATER_THAN_EQ"
+	case "<":
+		conditionType = "NUMBER_LESS"
+	default:
+		conditionType = "NUMBER_LESS_THAN_EQ"
+	}
+
+	// numbers are sent as Match compares them, without a % which Sheets would parse by locale
+	value := p.Value
+	if numeric && conditionType != "TEXT_CONTAINS" {
+		value = strconv.FormatFloat(expected, 'g', -1, 64)
+	}
+	return &sheets.BooleanCondition{
+		Type: conditionType,
+		Values: []*sheets.ConditionValue{
+			{
+				UserEnteredValue: value,
+			},
+		},
+	}, nil
+}
+
+// parseNumber parses a finite number, dividing it by 100 when it has a trailing % like Sheets does.
+// NaN and infinities are text, as in Sheets.
+func parseNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	percent := strings.HasSuffix(value, "%")
+	number, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	if percent {
+		number /= 100
+	}
+	return number, true
+}
+
+func sliceIndex(slice []string, value string) int {
+	for index, element := range slice {
+		if element == value {
+			return index
+		}
+	}
+	return -1
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+)
+
+// evaluateCondition evaluates the numeric condition on a cell the way Sheets does, on the number it stores for the
+// user entered cell, where a trailing % divides by 100
+func evaluateCondition(t *testing.T, condition string, expected string, cell string) bool {
+	number, isNumber := parseNumber(cell)
+	value, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		t.Fatalf("condition value %s is not a plain number", expected)
+	}
+	if !isNumber {
+		return condition == "NUMBER_NOT_EQ"
+	}
+	switch condition {
+	case "NUMBER_EQ":
+		return number == value
+	case "NUMBER_NOT_EQ":
+		return number != value
+	case "NUMBER_GREATER":
+		return number > value
+	case "NUMBER_GREATER_THAN_EQ":
+		return number >= value
+	case "NUMBER_LESS":
+		return number < value
+	case "NUMBER_LESS_THAN_EQ":
+		return number <= value
+	}
+	t.Fatalf("unexpected condition %s", condition)
+	return false
+}
+
+func TestPredicateMatchesCondition(t *testing.T) {
+	expressions := []string{"regression>10%", "regression>=0.1", "regression<5%", "regression<=5", "regression==50%", "regression!=0.5"}
+	cells := []string{"12%", "0.12", "10%", "0.1", "5%", "5", "50%", "0.5", "-3%", "abc"}
+	for _, expression := range expressions {
+		predicate, err := ParsePredicate(expression)
+		if err != nil {
+			t.Fatalf("ParsePredicate(%s): %s", expression, err.Error())
+		}
+		condition, err := predicate.toBooleanCondition()
+		if err != nil {
+			t.Fatalf("toBooleanCondition(%s): %s", expression, err.Error())
+		}
+		for _, cell := range cells {
+			match, err := predicate.Match(cell)
+			if err != nil {
+				t.Fatalf("Match(%s): %s", cell, err.Error())
+			}
+			sheetsMatch := evaluateCondition(t, condition.Type, condition.Values[0].UserEnteredValue, cell)
+			if match != sheetsMatch {
+				t.Errorf("%s on %s: Match is %t but the %s %s condition is %t",
+					expression, cell, match, condition.Type, condition.Values[0].UserEnteredValue, sheetsMatch)
+			}
+		}
+	}
+}
+
+func TestPredicatePercent(t *testing.T) {
+	tests := []struct {
+		expression string
+		cell       string
+		match      bool
+	}{
+		{"regression>10%", "12%", true},
+		{"regression>10%", "0.12", true},
+		{"regression>10%", "8%", false},
+		{"regression>10%", "0.08", false},
+		{"share==50%", "0.5", true},
+		{"status==FAIL", "FAIL", true},
+		{"status~=AI", "FAIL", true},
+		{"status!=FAIL", "PASS", true},
+	}
+	for _, test := range tests {
+		predicate, err := ParsePredicate(test.expression)
+		if err != nil {
+			t.Fatalf("ParsePredicate(%s): %s", test.expression, err.Error())
+		}
+		match, err := predicate.Match(test.cell)
+		if err != nil {
+			t.Fatalf("Match(%s): %s", test.cell, err.Error())
+		}
+		if match != test.match {
+			t.Errorf("%s on %s: got %t, want %t", test.expression, test.cell, match, test.match)
+		}
+	}
+}
+
+func TestPredicateCondition(t *testing.T) {
+	tests := []struct {
+		expression    string
+		conditionType string
+		value         string
+	}{
+		{"regression>10%", "NUMBER_GREATER", "0.1"},
+		{"regression<=5", "NUMBER_LESS_THAN_EQ", "5"},
+		{"share==50%", "NUMBER_EQ", "0.5"},
+		{"status==FAIL", "TEXT_EQ", "FAIL"},
+		{"name~=10%", "TEXT_CONTAINS", "10%"},
+	}
+	for _, test := range tests {
+		predicate, err := ParsePredicate(test.expression)
+		if err != nil {
+			t.Fatalf("ParsePredicate(%s): %s", test.expression, err.Error())
+		}
+		condition, err := predicate.toBooleanCondition()
+		if err != nil {
+			t.Fatalf("toBooleanCondition(%s): %s", test.expression, err.Error())
+		}
+		if condition.Type != test.conditionType || condition.Values[0].UserEnteredValue != test.value {
+			t.Errorf("%s: got %s %s, want %s %s", test.expression, condition.Type, condition.Values[0].UserEnteredValue, test.conditionType, test.value)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// Report describes the columns added to the table and the formatting applied after it is inserted
+type Report struct {
+	// Query whose result is uploaded instead of --content-file
+	Sql *SqlQuery `json:"sql"`
+	// Applied to the table before it is uploaded to the first sheet
+	Transforms []*Transform `json:"transforms"`
+	// Extra tabs, each holding the table after its own transforms
+	Tabs []*Tab `json:"tabs"`
+
+	RowHighlights []*RowHighlight `json:"row_highlights"`
+	Banding       *Banding        `json:"banding"`
+	// Style of the header row, overriding the style preset
+	HeaderStyle *Style        `json:"header_style"`
+	Styles      []*RangeStyle `json:"styles"`
+	// Rows and columns kept visible while scrolling
+	FreezeRows    int  `json:"freeze_rows"`
+	FreezeColumns int  `json:"freeze_columns"`
+	AutoResize    bool `json:"auto_resize"`
+	// Pixel widths by header name, applied after auto resizing
+	ColumnWidths map[string]int64 `json:"column_widths"`
+	BasicFilter  bool             `json:"basic_filter"`
+	FilterViews  []*FilterView    `json:"filter_views"`
+	Validations  []*Validation    `json:"validations"`
+	// Columns computed in-sheet, appended after the uploaded columns
+	FormulaColumns []*FormulaColumn `json:"formula_columns"`
+	Summary        *Summary         `json:"summary"`
+	PivotTables    []*PivotTable    `json:"pivot_tables"`
+	// Highlights the cells changed since the previous run and lists added and removed rows on a tab
+	Diff *Diff `json:"diff"`
+	// Keeps the previous report of --google-sheet-id before it is recreated
+	Archive *Archive `json:"archive"`
+	// Shared with in addition to --users. With --sync-permissions, every other permission is removed
+	Permissions []*Permission `json:"permissions"`
+	// HTML body of the email sent with --send-email-message
+	Email *EmailOptions `json:"email"`
+	// Sends the email with Gmail, SMTP or a webhook. Defaults to Gmail
+	Notifier *NotifierConfig `json:"notifier"`
+}
+
+func ReadReportFromFile(filename string) (*Report, error) {
+	jsonFile, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer jsonFile.Close()
+
+	b, err := io.ReadAll(jsonFile)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	err = json.Unmarshal(b, report)
+	return report, err
+}
@@ -0,0 +1,53 @@
+package api
+
+import "google.golang.org/api/sheets/v4"
+
+// RowHighlight colors every table row whose cell in Column satisfies the predicate
+type RowHighlight struct {
+	Predicate
+	Color *Color `json:"color"`
+}
+
+// HighlightRows sets the background of the full width of each table row matching predicate.
+// table is the same table inserted at cellPosition, including its header row.
+func (s *Service) HighlightRows(spreadsheetId string, cellPosition *CellPosition, table [][]string, predicate *Predicate, color *Color) error {
+	matchingRows, err := predicate.MatchingRows(table)
+	if err != nil {
+		return err
+	}
+	if len(matchingRows) == 0 {
+		return nil
+	}
+
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	// group consecutive rows so each run is a single request
+	var requests []*sheets.Request
+	width := len(table[0])
+	for i := 0; i < len(matchingRows); {
+		j := i
+		for j+1 < len(matchingRows) && matchingRows[j+1] == matchingRows[j]+1 {
+			j++
+		}
+		requests = append(requests, &sheets.Request{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Cell: &sheets.CellData{
+					UserEnteredFormat: &sheets.CellFormat{
+						BackgroundColor: color.toSheetsColor(),
+					},
+				},
+				Fields: "userEnteredFormat.backgroundColor",
+				Range:  toGridRange(sheetId, cellPosition.Offset(matchingRows[i], 0), cellPosition.Offset(matchingRows[j], width-1)),
+			},
+		})
+		i = j + 1
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"fmt"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// OAuth scopes of the operations of Service
+const (
+	// Every operation on spreadsheets
+	SheetsScope = sheets.SpreadsheetsScope
+	// Drive operations on any file, e.g. sharing or moving an existing spreadsheet, or finding one by title
+	DriveScope = drive.DriveScope
+	// Drive operations on files created by the service only
+	DriveFileScope = drive.DriveFileScope
+	// Sending email with Gmail
+	GmailSendScope = gmail.GmailSendScope
+)
+
+// MissingScopeError is returned when an API denies a request because the token lacks the scope
+type MissingScopeError struct {
+	Scope string
+	// Whether the service requested the scope, which the token may still lack, e.g. when it was cached with fewer
+	// scopes or domain-wide delegation does not grant it
+	Requested bool
+}
+
+func (e *MissingScopeError) Error() string {
+	if !e.Requested {
+		return fmt.Sprintf("permission denied: the request needs OAuth scope %s, which the service was not created with", e.Scope)
+	}
+	return fmt.Sprintf("permission denied: the token lacks OAuth scope %s; grant it to the credentials, or remove a cached token to sign in again", e.Scope)
+}
+
+// Retries of requests rejected for exceeding the rate quota or while the API is unavailable
+const maxRetries = 4
+
+// Delay before the first retry, doubled for each retry after it
+var retryDelay = time.Second
+
+// serviceTransport counts and logs the requests of the service, and retries requests rejected with 429, or idempotent
+// requests rejected with 503, after an exponential backoff. Failed responses are returned as they are, for the API client to turn into errors.
+type serviceTransport struct {
+	base    http.RoundTripper
+	service *Service
+}
+
+func (t *serviceTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	resp, err := t.send(request)
+	for retry := 0; err == nil && retry < maxRetries && retryable(request, resp); retry++ {
+		delay := retryDelay << retry
+		seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if parseErr == nil && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+		resp.Body.Close()
+		atomic.AddInt64(&t.service.stats.Retries, 1)
+		t.service.logger.Warn("retrying request", "method", request.Method, "path", request.URL.Path, "status", resp.StatusCode, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-request.Context().Done():
+			timer.Stop()
+			return nil, request.Context().Err()
+		case <-timer.C:
+		}
+		retryRequest := request.Clone(request.Context())
+		if request.GetBody != nil {
+			retryRequest.Body, err = request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+		resp, err = t.send(retryRequest)
+	}
+	return resp, err
+}
+
+// send makes the request, counting it with the bytes of its body and logging it at debug level
+func (t *serviceTransport) send(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		request = request.Clone(request.Context())
+		request.Body = &countingBody{
+			ReadCloser: request.Body,
+			count:      &t.service.stats.BytesSent,
+		}
+	}
+	atomic.AddInt64(&t.service.stats.Requests, 1)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(request)
+	if err != nil {
+		t.service.logger.Debug("request failed", "method", request.Method, "path", request.URL.Path, "error", err)
+		return nil, err
+	}
+	t.service.logger.Debug("request", "method", request.Method, "path", request.URL.Path, "status", resp.StatusCode,
+		"duration_ms", time.Since(start).Milliseconds())
+	return resp, nil
+}
+
+// retryable returns whether the request was rejected for exceeding the rate quota, or while the API was unavailable
+// and it is idempotent, and can be sent again. An unavailable API may have handled the request, so requests which
+// create something, e.g. a spreadsheet, a sheet or a permission, are not sent twice.
+func retryable(request *http.Request, resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusServiceUnavailable:
+		if !idempotent(request) {
+			return false
+		}
+	default:
+		return false
+	}
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+// idempotent returns whether sending the request twice has the effect of sending it once
+func idempotent(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	// values.clear is a POST
+	return request.Method == http.MethodPost && strings.HasSuffix(request.URL.Path, ":clear")
+}
+
+// countingBody adds the bytes read from the body of a request to count
+type countingBody struct {
+	io.ReadCloser
+	count *int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.count, int64(n))
+	return n, err
+}
+
+// clientOption authenticates, counts and retries the requests of an API
+func (s *Service) clientOption() option.ClientOption {
+	return option.WithHTTPClient(&http.Client{
+		Transport: &serviceTransport{
+			base: &oauth2.Transport{
+				Source: s.tokenSource,
+				Base:   http.DefaultTransport,
+			},
+			service: s,
+		},
+	})
+}
+
+// sheetsService returns the Sheets client, creating it on first use.
+// Clients are created with an HTTP client, so creating them does not fail.
+func (s *Service) sheetsService() *sheets.Service {
+	s.sheetsOnce.Do(func() {
+		s.sheets, _ = sheets.NewService(s.ctx, s.clientOption())
+	})
+	return s.sheets
+}
+
+// driveService returns the Drive client, creating it on first use
+func (s *Service) driveService() *drive.Service {
+	s.driveOnce.Do(func() {
+		s.drive, _ = drive.NewService(s.ctx, s.clientOption())
+	})
+	return s.drive
+}
+
+// gmailService returns the Gmail client, creating it on first use
+func (s *Service) gmailService() *gmail.Service {
+	s.gmailOnce.Do(func() {
+		s.gmail, _ = gmail.NewService(s.ctx, s.clientOption())
+	})
+	return s.gmail
+}
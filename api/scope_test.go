@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"google.golang.org/api/sheets/v4"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetries(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = time.Millisecond
+
+	tests := []struct {
+		name     string
+		status   int
+		call     func(s *Service) error
+		requests int64
+	}{
+		{"get on 503", http.StatusServiceUnavailable, func(s *Service) error {
+			_, err := s.getSheetsProperties("id")
+			return err
+		}, 1 + maxRetries},
+		{"values.update on 503", http.StatusServiceUnavailable, func(s *Service) error {
+			return s.writeTable("id", "", Origin(), [][]string{{"a"}})
+		}, 1 + maxRetries},
+		{"values.clear on 503", http.StatusServiceUnavailable, func(s *Service) error {
+			_, err := s.sheetsService().Spreadsheets.Values.Clear("id", "Sheet1", &sheets.ClearValuesRequest{}).Do()
+			return err
+		}, 1 + maxRetries},
+		{"batchUpdate on 503", http.StatusServiceUnavailable, func(s *Service) error {
+			_, err := s.sheetsService().Spreadsheets.BatchUpdate("id", &sheets.BatchUpdateSpreadsheetRequest{
+				Requests: []*sheets.Request{{AddSheet: &sheets.AddSheetRequest{}}},
+			}).Do()
+			return s.sheetsError(err, "id", "")
+		}, 1},
+		{"create on 503", http.StatusServiceUnavailable, func(s *Service) error {
+			_, err := s.Create("title")
+			return err
+		}, 1},
+		{"batchUpdate on 429", http.StatusTooManyRequests, func(s *Service) error {
+			_, err := s.sheetsService().Spreadsheets.BatchUpdate("id", &sheets.BatchUpdateSpreadsheetRequest{
+				Requests: []*sheets.Request{{AddSheet: &sheets.AddSheetRequest{}}},
+			}).Do()
+			return s.sheetsError(err, "id", "")
+		}, 1 + maxRetries},
+		{"create on 429", http.StatusTooManyRequests, func(s *Service) error {
+			_, err := s.Create("title")
+			return err
+		}, 1 + maxRetries},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int64
+			s := testService(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt64(&requests, 1)
+				respond(w, test.status, `{"error": {"code": 0, "message": "try again"}}`)
+			})
+			err := test.call(s)
+			if err == nil {
+				t.Fatalf("got no error")
+			}
+			if test.status == http.StatusTooManyRequests && !errors.Is(err, ErrQuotaExceeded) {
+				t.Errorf("got %v, want ErrQuotaExceeded", err)
+			}
+			if requests != test.requests {
+				t.Errorf("got %d requests, want %d", requests, test.requests)
+			}
+			if stats := s.Stats(); stats.Requests != test.requests || stats.Retries != test.requests-1 {
+				t.Errorf("got stats %+v, want %d requests", stats, test.requests)
+			}
+		})
+	}
+}
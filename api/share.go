@@ -0,0 +1,184 @@
+package api
+
+import (
+	"google.golang.org/api/drive/v3"
+	"strings"
+)
+
+// Permission grants a role on a file to a user, a group, a domain or anyone with the link
+type Permission struct {
+	// Set by ListPermissions, and used to revoke the permission
+	Id string `json:"id"`
+	// user, group, domain or anyone. Defaults to user
+	Type string `json:"type"`
+	// reader, commenter, writer or owner. Defaults to writer
+	Role string `json:"role"`
+	// Email of the user or group
+	EmailAddress string `json:"email_address"`
+	Domain       string `json:"domain"`
+	// Lets domain and anyone permissions find the file in search, instead of only opening it by link
+	AllowFileDiscovery bool `json:"allow_file_discovery"`
+	// Set by ListPermissions when the permission only comes from the shared drive or a folder holding the file,
+	// so it cannot be removed from the file
+	Inherited bool `json:"-"`
+}
+
+// ShareOptions configure the email Drive sends to users and groups given a permission
+type ShareOptions struct {
+	// Added to the email
+	Message string
+	// Grants the permission without an email. Ownership transfers always send an email
+	SuppressNotification bool
+}
+
+var (
+	permissionTypes = []string{"user", "group", "domain", "anyone"}
+	permissionRoles = []string{"reader", "commenter", "writer", "owner"}
+)
+
+// ParsePermission parses [type:]target[:role], where target is the email of a user or group, or a domain.
+// anyone has no target, e.g. anyone:reader. The type defaults to user and the role to writer.
+func ParsePermission(entry string) (*Permission, error) {
+	parts := strings.Split(strings.TrimSpace(entry), ":")
+	permission := &Permission{
+		Type: "user",
+		Role: "writer",
+	}
+	if sliceIndex(permissionTypes, parts[0]) >= 0 {
+		permission.Type = parts[0]
+		parts = parts[1:]
+	}
+	if permission.Type != "anyone" {
+		if len(parts) == 0 || parts[0] == "" {
+			return nil, validationErrorf("missing %s in permission: %s", permission.Type, entry)
+		}
+		if permission.Type == "domain" {
+			permission.Domain = parts[0]
+		} else {
+			permission.EmailAddress = parts[0]
+		}
+		parts = parts[1:]
+	}
+	if len(parts) > 1 {
+		return nil, validationErrorf("invalid permission: %s", entry)
+	}
+	if len(parts) == 1 {
+		permission.Role = parts[0]
+	}
+	err := permission.validate()
+	if err != nil {
+		return nil, err
+	}
+	return permission, nil
+}
+
+// withDefaults returns a copy of the permission with the default type and role set
+func (p *Permission) withDefaults() *Permission {
+	permission := *p
+	if permission.Type == "" {
+		permission.Type = "user"
+	}
+	if permission.Role == "" {
+		permission.Role = "writer"
+	}
+	return &permission
+}
+
+func (p *Permission) validate() error {
+	if sliceIndex(permissionTypes, p.Type) < 0 {
+		return validationErrorf("unknown permission type: %s", p.Type)
+	}
+	if sliceIndex(permissionRoles, p.Role) < 0 {
+		return validationErrorf("unknown permission role: %s", p.Role)
+	}
+	if p.Role == "owner" && p.Type != "user" {
+		return validationErrorf("only a user can be owner")
+	}
+	return nil
+}
+
+// SharePermission grants the permission on the file. A user given the owner role becomes the owner of the file.
+// Nil options are the zero options.
+func (s *Service) SharePermission(fileId string, permission *Permission, options *ShareOptions) error {
+	if options == nil {
+		options = &ShareOptions{}
+	}
+	permission = permission.withDefaults()
+	err := permission.validate()
+	if err != nil {
+		return err
+	}
+
+	call := s.driveService().Permissions.Create(fileId, &drive.Permission{
+		Type:               permission.Type,
+		Role:               permission.Role,
+		EmailAddress:       permission.EmailAddress,
+		Domain:             permission.Domain,
+		AllowFileDiscovery: permission.AllowFileDiscovery,
+	}).SupportsAllDrives(true)
+	if permission.Role == "owner" {
+		call = call.TransferOwnership(true)
+	} else if permission.Type == "user" || permission.Type == "group" {
+		if options.SuppressNotification {
+			call = call.SendNotificationEmail(false)
+		} else if options.Message != "" {
+			call = call.EmailMessage(options.Message)
+		}
+	}
+	_, err = call.Do()
+	return s.driveError(err, fileId)
+}
+
+// ListPermissions returns the permissions of the file
+func (s *Service) ListPermissions(fileId string) ([]*Permission, error) {
+	var permissions []*Permission
+	pageToken := ""
+	for {
+		list, err := s.driveService().Permissions.List(fileId).SupportsAllDrives(true).
+			Fields("nextPageToken", "permissions(id,type,role,emailAddress,domain,allowFileDiscovery,permissionDetails(inherited))").
+			PageToken(pageToken).Do()
+		if err != nil {
+			return nil, s.driveError(err, fileId)
+		}
+		for _, permission := range list.Permissions {
+			permissions = append(permissions, &Permission{
+				Id:                 permission.Id,
+				Type:               permission.Type,
+				Role:               permission.Role,
+				EmailAddress:       permission.EmailAddress,
+				Domain:             permission.Domain,
+				AllowFileDiscovery: permission.AllowFileDiscovery,
+				Inherited:          inherited(permission),
+			})
+		}
+		pageToken = list.NextPageToken
+		if pageToken == "" {
+			return permissions, nil
+		}
+	}
+}
+
+// inherited returns whether every part of the permission is inherited
+func inherited(permission *drive.Permission) bool {
+	for _, details := range permission.PermissionDetails {
+		if !details.Inherited {
+			return false
+		}
+	}
+	return len(permission.PermissionDetails) > 0
+}
+
+// AccountEmail returns the email of the account the service acts as
+func (s *Service) AccountEmail() (string, error) {
+	about, err := s.driveService().About.Get().Fields("user(emailAddress)").Do()
+	if err != nil {
+		return "", s.driveError(err, "")
+	}
+	return about.User.EmailAddress, nil
+}
+
+// Revoke removes the permission with the id returned by ListPermissions
+func (s *Service) Revoke(fileId string, permissionId string) error {
+	err := s.driveService().Permissions.Delete(fileId, permissionId).SupportsAllDrives(true).Do()
+	return s.driveError(err, fileId)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestSharePermissionOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *ShareOptions
+		want    map[string]string
+	}{
+		{
+			name:    "nil options",
+			options: nil,
+			want:    map[string]string{"sendNotificationEmail": "", "emailMessage": ""},
+		},
+		{
+			name:    "message",
+			options: &ShareOptions{Message: "hello"},
+			want:    map[string]string{"sendNotificationEmail": "", "emailMessage": "hello"},
+		},
+		{
+			name:    "suppressed notification",
+			options: &ShareOptions{Message: "hello", SuppressNotification: true},
+			want:    map[string]string{"sendNotificationEmail": "false", "emailMessage": ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := testService(t, func(w http.ResponseWriter, r *http.Request) {
+				for parameter, want := range test.want {
+					if got := r.URL.Query().Get(parameter); got != want {
+						t.Errorf("got %s=%q, want %q", parameter, got, want)
+					}
+				}
+				io.WriteString(w, `{}`)
+			})
+			err := s.SharePermission("id", &Permission{Type: "user", EmailAddress: "a@example.com"}, test.options)
+			if err != nil {
+				t.Errorf("SharePermission: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go-source/local"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TableSource reads a table one row at a time. The first row is the header.
+type TableSource interface {
+	// Next returns the next row, or io.EOF after the last row
+	Next() ([]string, error)
+	Close() error
+}
+
+// TextColumns returns the columns of the source whose values are text, e.g. the text columns of a SQL query,
+// which Sheets would otherwise parse as numbers or formulas. Files have no such columns.
+func TextColumns(source TableSource) []string {
+	if typed, ok := source.(interface{ textColumns() []string }); ok {
+		return typed.textColumns()
+	}
+	return nil
+}
+
+// QuoteText returns the table to write with the values of the text columns which Sheets would parse as a number
+// or formula prefixed with ', which keeps them as text. The table itself is not changed.
+func QuoteText(table [][]string, columns []string) [][]string {
+	if len(table) == 0 || len(columns) == 0 {
+		return table
+	}
+	text := textIndices(table[0], columns)
+	quoted := [][]string{table[0]}
+	for _, row := range table[1:] {
+		quoted = append(quoted, quoteRow(row, text))
+	}
+	return quoted
+}
+
+// textIndices returns whether each column of the header is one of the text columns
+func textIndices(header []string, columns []string) []bool {
+	text := make([]bool, len(header))
+	for i, column := range header {
+		text[i] = sliceIndex(columns, column) >= 0
+	}
+	return text
+}
+
+// quoteRow returns the row with its text values which Sheets would parse prefixed with '
+func quoteRow(row []string, text []bool) []string {
+	quoted := make([]string, len(row))
+	for i, cell := range row {
+		quoted[i] = cell
+		if i >= len(text) || !text[i] {
+			continue
+		}
+		if _, isNumber := parseNumber(cell); isNumber || strings.HasPrefix(cell, "=") || strings.HasPrefix(cell, "'") {
+			quoted[i] = "'" + cell
+		}
+	}
+	return quoted
+}
+
+// SourceOptions configure how OpenTableSource reads a file
+type SourceOptions struct {
+	// csv, tsv, json, jsonl, xlsx or parquet. Defaults to the format of the file extension, or csv
+	Format string
+	// Field delimiter of csv. Defaults to , for csv and tab for tsv
+	Delimiter rune
+	// Lines of csv and tsv starting with Comment are skipped
+	Comment rune
+	// Allows quotes in unquoted csv fields and unescaped quotes in quoted fields
+	LazyQuotes bool
+	// The first row of csv and tsv is data, and the columns are named A, B, C...
+	NoHeader bool
+	// Sheet of xlsx to read. Defaults to the first sheet
+	Sheet string
+}
+
+var sourceFormats = map[string]string{
+	".csv":     "csv",
+	".tsv":     "tsv",
+	".tab":     "tsv",
+	".json":    "json",
+	".jsonl":   "jsonl",
+	".ndjson":  "jsonl",
+	".xlsx":    "xlsx",
+	".parquet": "parquet",
+}
+
+// OpenTableSource opens the file for reading as a table, or stdin when filename is -
+func OpenTableSource(filename string, options *SourceOptions) (TableSource, error) {
+	format := options.Format
+	if format == "" {
+		format = sourceFormats[strings.ToLower(filepath.Ext(filename))]
+	}
+	if format == "" {
+		format = "csv"
+	}
+
+	if format == "parquet" {
+		return openParquetSource(filename)
+	}
+
+	var file io.ReadCloser = os.Stdin
+	if filename != "-" {
+		var err error
+		file, err = os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case "csv", "tsv":
+		delimiter := options.Delimiter
+		if delimiter == 0 && format == "tsv" {
+			delimiter = '\t'
+		}
+		return NewCsvSource(file, &CsvOptions{
+			Delimiter:  delimiter,
+			Comment:    options.Comment,
+			LazyQuotes: options.LazyQuotes,
+			NoHeader:   options.NoHeader,
+		}), nil
+	case "json":
+		return NewJsonSource(file), nil
+	case "jsonl":
+		return NewJsonLinesSource(file), nil
+	case "xlsx":
+		defer file.Close()
+		return NewXlsxSource(file, options.Sheet)
+	default:
+		file.Close()
+		return nil, validationErrorf("unknown table format: %s", format)
+	}
+}
+
+// openParquetSource reads parquet from a local file, or buffers stdin since parquet is read out of order
+func openParquetSource(filename string) (TableSource, error) {
+	if filename != "-" {
+		file, err := local.NewLocalFileReader(filename)
+		if err != nil {
+			return nil, err
+		}
+		return NewParquetSource(file)
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return NewParquetSource(buffer.NewBufferFileFromBytes(data))
+}
+
+// ReadAll reads the remaining rows of the source and closes it
+func ReadAll(source TableSource) ([][]string, error) {
+	defer source.Close()
+
+	var table [][]string
+	for {
+		row, err := source.Next()
+		if err == io.EOF {
+			return table, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		table = append(table, row)
+	}
+}
+
+// columnHeader names the columns A, B, C... for tables without a header row
+func columnHeader(width int) []string {
+	header := make([]string, width)
+	for i := range header {
+		header[i], _ = indexToColumn(i + 1)
+	}
+	return header
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SqlQuery is a query whose result is uploaded instead of a file
+type SqlQuery struct {
+	// Registered database/sql driver. Defaults to sqlite
+	Driver string `json:"driver"`
+	// Data source name, e.g. the sqlite file
+	Dsn string `json:"dsn"`
+	// Environment variable holding the data source name, to keep credentials out of the config
+	DsnEnv string `json:"dsn_env"`
+	Query  string `json:"query"`
+}
+
+// Open runs the query with OpenSqlSource
+func (q *SqlQuery) Open() (TableSource, error) {
+	driver := q.Driver
+	if driver == "" {
+		driver = "sqlite"
+	}
+	dsn := q.Dsn
+	if q.DsnEnv != "" {
+		dsn = os.Getenv(q.DsnEnv)
+		if dsn == "" {
+			return nil, validationErrorf("environment variable %s is empty", q.DsnEnv)
+		}
+	}
+	return OpenSqlSource(driver, dsn, q.Query)
+}
+
+type sqlSource struct {
+	// closed with the source when it was opened by the source
+	db     *sql.DB
+	rows   *sql.Rows
+	header []string
+	width  int
+	// names of the columns of text or binary types
+	text []string
+}
+
+// Database type names, or parts of them, of columns whose values are text
+var textTypeNames = []string{"CHAR", "TEXT", "CLOB", "STRING", "BLOB", "BINARY"}
+
+// OpenSqlSource connects to the database with a registered database/sql driver and runs the query.
+// The connection is closed with the source.
+func OpenSqlSource(driver string, dsn string, query string) (TableSource, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	source, err := NewSqlSource(db, query)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	source.(*sqlSource).db = db
+	return source, nil
+}
+
+// NewSqlSource runs the query and streams its result rows. The header is the result column names.
+// Numbers, booleans and times are formatted for sheets to parse into typed cells. Columns of text or binary types
+// are given by TextColumns, so that their values are written as text. Closing the source leaves db open.
+func NewSqlSource(db *sql.DB, query string, args ...interface{}) (TableSource, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	source := &sqlSource{
+		rows:  rows,
+		width: len(columnTypes),
+	}
+	for _, columnType := range columnTypes {
+		source.header = append(source.header, columnType.Name())
+		typeName := strings.ToUpper(columnType.DatabaseTypeName())
+		for _, textTypeName := range textTypeNames {
+			if strings.Contains(typeName, textTypeName) {
+				source.text = append(source.text, columnType.Name())
+				break
+			}
+		}
+	}
+	return source, nil
+}
+
+func (s *sqlSource) Next() ([]string, error) {
+	if s.header != nil {
+		header := s.header
+		s.header = nil
+		return header, nil
+	}
+
+	if !s.rows.Next() {
+		err := s.rows.Err()
+		if err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	values := make([]interface{}, s.width)
+	pointers := make([]interface{}, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	err := s.rows.Scan(pointers...)
+	if err != nil {
+		return nil, err
+	}
+
+	row := make([]string, len(values))
+	for i, value := range values {
+		row[i] = sqlCell(value)
+	}
+	return row, nil
+}
+
+func (s *sqlSource) textColumns() []string {
+	return s.text
+}
+
+func (s *sqlSource) Close() error {
+	err := s.rows.Close()
+	if s.db != nil {
+		dbErr := s.db.Close()
+		if err == nil {
+			err = dbErr
+		}
+	}
+	return err
+}
+
+func sqlCell(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(typed, 10)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		if typed {
+			return "TRUE"
+		}
+		return "FALSE"
+	case time.Time:
+		if typed.Hour() == 0 && typed.Minute() == 0 && typed.Second() == 0 && typed.Nanosecond() == 0 {
+			return typed.Format("2006-01-02")
+		}
+		return typed.Format("2006-01-02 15:04:05")
+	case []byte:
+		return string(typed)
+	case string:
+		return typed
+	default:
+		return fmt.Sprint(typed)
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"google.golang.org/api/sheets/v4"
+	"io"
+	_ "modernc.org/sqlite"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func openTestDatabase(t *testing.T, dsn string) *sql.DB {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %s", err.Error())
+	}
+	// every connection to :memory: is a new database
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`
+		CREATE TABLE results (id INTEGER, score REAL, name TEXT, note TEXT, data BLOB);
+		INSERT INTO results VALUES
+			(1, 2.5, 'alpha', NULL, x'616263'),
+			(-7, 0.125, '42', 'x', x'3132'),
+			(3, NULL, '=SUM(A1)', '''quoted', NULL);
+	`)
+	if err != nil {
+		t.Fatalf("failed to create table: %s", err.Error())
+	}
+	return db
+}
+
+func TestSqlSourceTypes(t *testing.T) {
+	db := openTestDatabase(t, ":memory:")
+	defer db.Close()
+
+	source, err := NewSqlSource(db, "SELECT id, score, name, note, data FROM results ORDER BY rowid")
+	if err != nil {
+		t.Fatalf("NewSqlSource: %s", err.Error())
+	}
+	table, err := ReadAll(source)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err.Error())
+	}
+
+	want := [][]string{
+		{"id", "score", "name", "note", "data"},
+		// INTEGER and REAL are numbers, NULL is empty, and BLOB is its text
+		{"1", "2.5", "alpha", "", "abc"},
+		// values are kept as they are in the table
+		{"-7", "0.125", "42", "x", "12"},
+		{"3", "", "=SUM(A1)", "'quoted", ""},
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("got %q, want %q", table, want)
+	}
+
+	// and only quoted when written, where sheets would parse TEXT and BLOB as a number or formula
+	textColumns := TextColumns(source)
+	if !reflect.DeepEqual(textColumns, []string{"name", "note", "data"}) {
+		t.Errorf("got text columns %q", textColumns)
+	}
+	quoted := QuoteText(table, textColumns)
+	wantQuoted := [][]string{
+		{"id", "score", "name", "note", "data"},
+		{"1", "2.5", "alpha", "", "abc"},
+		{"-7", "0.125", "'42", "x", "'12"},
+		{"3", "", "'=SUM(A1)", "''quoted", ""},
+	}
+	if !reflect.DeepEqual(quoted, wantQuoted) {
+		t.Errorf("got quoted %q, want %q", quoted, wantQuoted)
+	}
+	if table[2][2] != "42" {
+		t.Errorf("QuoteText changed the table")
+	}
+}
+
+func TestSqlQueryConfig(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db := openTestDatabase(t, dsn)
+	db.Close()
+
+	t.Setenv("TEST_SQL_DSN", dsn)
+	source, err := (&SqlQuery{DsnEnv: "TEST_SQL_DSN", Query: "SELECT name FROM results ORDER BY rowid"}).Open()
+	if err != nil {
+		t.Fatalf("Open: %s", err.Error())
+	}
+	table, err := ReadAll(source)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err.Error())
+	}
+	if !reflect.DeepEqual(table, [][]string{{"name"}, {"alpha"}, {"42"}, {"=SUM(A1)"}}) {
+		t.Errorf("got %q", table)
+	}
+
+	_, err = (&SqlQuery{DsnEnv: "TEST_SQL_MISSING", Query: "SELECT 1"}).Open()
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("got %v, want ErrValidation for an empty environment variable", err)
+	}
+}
+
+func TestSqlSourceStreamsRows(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db := openTestDatabase(t, dsn)
+	db.Close()
+
+	source, err := OpenSqlSource("sqlite", dsn, "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i+1 FROM n WHERE i < 5000) SELECT i FROM n")
+	if err != nil {
+		t.Fatalf("OpenSqlSource: %s", err.Error())
+	}
+	defer source.Close()
+
+	header, err := source.Next()
+	if err != nil || !reflect.DeepEqual(header, []string{"i"}) {
+		t.Fatalf("got header %q, %v", header, err)
+	}
+	count := 0
+	for {
+		row, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %s", err.Error())
+		}
+		count++
+		if row[0] != formatNumber(float64(count)) {
+			t.Fatalf("row %d is %q", count, row)
+		}
+	}
+	if count != 5000 {
+		t.Errorf("got %d rows, want 5000", count)
+	}
+	_, err = source.Next()
+	if err != io.EOF {
+		t.Errorf("Next after the last row: got %v, want io.EOF", err)
+	}
+}
+
+func TestSqlSourceStreamQuotesText(t *testing.T) {
+	db := openTestDatabase(t, ":memory:")
+	defer db.Close()
+
+	var written [][]string
+	s := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			io.WriteString(w, `{"sheets": [{"properties": {"sheetId": 0, "title": "Sheet1", "gridProperties": {"rowCount": 1000, "columnCount": 26}}}]}`)
+			return
+		}
+		var values sheets.ValueRange
+		json.NewDecoder(r.Body).Decode(&values)
+		for _, row := range values.Values {
+			var cells []string
+			for _, cell := range row {
+				cells = append(cells, cell.(string))
+			}
+			written = append(written, cells)
+		}
+		io.WriteString(w, `{}`)
+	})
+
+	source, err := NewSqlSource(db, "SELECT id, name FROM results ORDER BY rowid")
+	if err != nil {
+		t.Fatalf("NewSqlSource: %s", err.Error())
+	}
+	_, _, err = s.InsertTableFromSource("id", Origin(), source, &StreamOptions{})
+	if err != nil {
+		t.Fatalf("InsertTableFromSource: %s", err.Error())
+	}
+	want := [][]string{{"id", "name"}, {"1", "alpha"}, {"-7", "'42"}, {"3", "'=SUM(A1)"}}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("got %q, want %q", written, want)
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"google.golang.org/api/sheets/v4"
+	"io"
+	"sync"
+)
+
+// Approximate overhead of each cell in the JSON of a request, for quotes and separators
+const cellOverheadBytes = 4
+
+// StreamOptions configure InsertTableFromSource
+type StreamOptions struct {
+	// Upper bound on the approximate size of the values written by one request. Defaults to 2 MB
+	ChunkBytes int
+	// Number of chunks written at the same time. Defaults to 1
+	Parallelism int
+	// Called after each chunk is written with the number of rows written so far, including the header
+	Progress func(rows int)
+}
+
+// InsertTableFromSource reads the source incrementally and writes it to the first sheet at cellPosition
+// in chunks of bounded size, appending rows and columns to the sheet as they are needed.
+// The source is read only once, so each chunk is checked against the limits of a spreadsheet only before it is
+// written, after the earlier chunks. When a chunk fails a check, or reading or writing fails, the values of the sheet
+// are cleared so that no partial table is left, while rows and columns appended to the sheet are kept.
+// Values of the TextColumns of the source are written as text.
+// It closes the source and returns the header and the number of rows written, including the header.
+func (s *Service) InsertTableFromSource(spreadsheetId string, cellPosition *CellPosition, source TableSource, options *StreamOptions) ([]string, int, error) {
+	defer source.Close()
+
+	chunkBytes := options.ChunkBytes
+	if chunkBytes <= 0 {
+		chunkBytes = 2 << 20
+	}
+	parallelism := options.Parallelism
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	header, err := source.Next()
+	if err == io.EOF {
+		return nil, 0, &Error{
+			Kind:          ErrEmptyTable,
+			SpreadsheetId: spreadsheetId,
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	text := textIndices(header, TextColumns(source))
+
+	sheetsProperties, err := s.getSheetsProperties(spreadsheetId)
+	if err != nil {
+		return nil, 0, err
+	}
+	properties, err := findSheetProperties(sheetsProperties, "")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		mutex    sync.Mutex
+		written  int
+		writeErr error
+		wait     sync.WaitGroup
+		// whether any chunk was handed to a writer, so the sheet may hold part of the table
+		flushed bool
+	)
+	slots := make(chan struct{}, parallelism)
+	failed := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return writeErr != nil
+	}
+
+	// rows are handed to a writer once the chunk is full, while the next chunk is read
+	write := func(chunkPosition *CellPosition, chunk [][]string) {
+		defer wait.Done()
+		defer func() { <-slots }()
+
+		err := s.writeTable(spreadsheetId, "", chunkPosition, chunk)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			if writeErr == nil {
+				writeErr = err
+			}
+			return
+		}
+		written += len(chunk)
+		if options.Progress != nil {
+			options.Progress(written)
+		}
+	}
+	flush := func(chunkPosition *CellPosition, chunk [][]string) error {
+		err := checkCellLengths(chunkPosition, chunk)
+		if err != nil {
+			return err
+		}
+		// grid changes are made here, in order, before any write which needs them
+		err = s.ensureGridSize(spreadsheetId, sheetsProperties, properties,
+			int64(chunkPosition.RowIndex+len(chunk)-1), int64(chunkPosition.ColumnIndex+tableWidth(chunk)-1))
+		if err != nil {
+			return err
+		}
+		flushed = true
+		slots <- struct{}{}
+		wait.Add(1)
+		go write(chunkPosition, chunk)
+		return nil
+	}
+
+	rows := 1
+	chunkPosition := cellPosition
+	chunk := [][]string{header}
+	size := rowBytes(header)
+	var readErr error
+	for !failed() {
+		var row []string
+		row, readErr = source.Next()
+		if readErr == io.EOF {
+			readErr = nil
+			break
+		}
+		if readErr != nil {
+			break
+		}
+		row = quoteRow(row, text)
+
+		if len(chunk) > 0 && size+rowBytes(row) > chunkBytes {
+			readErr = flush(chunkPosition, chunk)
+			if readErr != nil {
+				break
+			}
+			chunkPosition = cellPosition.Offset(rows, 0)
+			chunk = nil
+			size = 0
+		}
+		chunk = append(chunk, row)
+		size += rowBytes(row)
+		rows++
+	}
+	if readErr == nil && len(chunk) > 0 && !failed() {
+		readErr = flush(chunkPosition, chunk)
+	}
+	wait.Wait()
+
+	err = readErr
+	if err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		if flushed {
+			s.clearPartialTable(spreadsheetId, properties.Title)
+		}
+		return nil, 0, err
+	}
+	return header, rows, nil
+}
+
+// clearPartialTable clears the values of the sheet after a failed upload, logging when that fails too
+func (s *Service) clearPartialTable(spreadsheetId string, title string) {
+	_, err := s.sheetsService().Spreadsheets.Values.Clear(spreadsheetId, sheetRange(title), &sheets.ClearValuesRequest{}).Do()
+	if err != nil {
+		s.logger.Warn("failed to clear the partly written sheet", "spreadsheet", spreadsheetId, "sheet", title, "error", err)
+	}
+}
+
+func rowBytes(row []string) int {
+	size := 0
+	for _, cell := range row {
+		size += len(cell) + cellOverheadBytes
+	}
+	return size
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"google.golang.org/api/sheets/v4"
+	"strings"
+)
+
+// Style is the text format, alignment, wrapping and borders of a range.
+// Unset fields leave the existing format of the cells unchanged.
+type Style struct {
+	Bold       *bool  `json:"bold"`
+	Italic     *bool  `json:"italic"`
+	FontSize   int64  `json:"font_size"`
+	FontFamily string `json:"font_family"`
+	TextColor  *Color `json:"text_color"`
+	// LEFT, CENTER or RIGHT
+	HorizontalAlignment string `json:"horizontal_alignment"`
+	// TOP, MIDDLE or BOTTOM
+	VerticalAlignment string `json:"vertical_alignment"`
+	// OVERFLOW_CELL, CLIP or WRAP
+	WrapStrategy string   `json:"wrap_strategy"`
+	Borders      *Borders `json:"borders"`
+}
+
+type Borders struct {
+	Top             *Border `json:"top"`
+	Bottom          *Border `json:"bottom"`
+	Left            *Border `json:"left"`
+	Right           *Border `json:"right"`
+	InnerHorizontal *Border `json:"inner_horizontal"`
+	InnerVertical   *Border `json:"inner_vertical"`
+}
+
+type Border struct {
+	// SOLID, SOLID_MEDIUM, SOLID_THICK, DASHED, DOTTED, DOUBLE or NONE
+	Style string `json:"style"`
+	Color *Color `json:"color"`
+}
+
+// RangeStyle applies a style to a range in A1 notation, e.g. A1:D1
+type RangeStyle struct {
+	Style
+	Range string `json:"range"`
+}
+
+// ReportHeaderStyle bolds the header row and draws solid borders around each of its cells
+func ReportHeaderStyle() *Style {
+	bold := true
+	border := &Border{
+		Style: "SOLID",
+		Color: &Color{A: 1},
+	}
+	return &Style{
+		Bold:              &bold,
+		VerticalAlignment: "MIDDLE",
+		Borders: &Borders{
+			Top:           border,
+			Bottom:        border,
+			Left:          border,
+			Right:         border,
+			InnerVertical: border,
+		},
+	}
+}
+
+func (s *Service) SetStyle(spreadsheetId string, startPosition *CellPosition, endPosition *CellPosition, style *Style) error {
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+	gridRange := toGridRange(sheetId, startPosition, endPosition)
+
+	var requests []*sheets.Request
+	format, fields := style.toCellFormat()
+	if len(fields) > 0 {
+		requests = append(requests, &sheets.Request{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Cell: &sheets.CellData{
+					UserEnteredFormat: format,
+				},
+				Fields: strings.Join(fields, ","),
+				Range:  gridRange,
+			},
+		})
+	}
+	if style.Borders != nil {
+		requests = append(requests, &sheets.Request{
+			UpdateBorders: &sheets.UpdateBordersRequest{
+				Range:           gridRange,
+				Top:             style.Borders.Top.toSheetsBorder(),
+				Bottom:          style.Borders.Bottom.toSheetsBorder(),
+				Left:            style.Borders.Left.toSheetsBorder(),
+				Right:           style.Borders.Right.toSheetsBorder(),
+				InnerHorizontal: style.Borders.InnerHorizontal.toSheetsBorder(),
+				InnerVertical:   style.Borders.InnerVertical.toSheetsBorder(),
+			},
+		})
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+// toCellFormat returns the cell format together with the field mask of the fields which are set
+func (style *Style) toCellFormat() (*sheets.CellFormat, []string) {
+	format := &sheets.CellFormat{
+		TextFormat:          &sheets.TextFormat{},
+		HorizontalAlignment: style.HorizontalAlignment,
+		VerticalAlignment:   style.VerticalAlignment,
+		WrapStrategy:        style.WrapStrategy,
+	}
+
+	var fields []string
+	if style.Bold != nil {
+		format.TextFormat.Bold = *style.Bold
+		fields = append(fields, "userEnteredFormat.textFormat.bold")
+	}
+	if style.Italic != nil {
+		format.TextFormat.Italic = *style.Italic
+		fields = append(fields, "userEnteredFormat.textFormat.italic")
+	}
+	if style.FontSize != 0 {
+		format.TextFormat.FontSize = style.FontSize
+		fields = append(fields, "userEnteredFormat.textFormat.fontSize")
+	}
+	if style.FontFamily != "" {
+		format.TextFormat.FontFamily = style.FontFamily
+		fields = append(fields, "userEnteredFormat.textFormat.fontFamily")
+	}
+	if style.TextColor != nil {
+		format.TextFormat.ForegroundColor = style.TextColor.toSheetsColor()
+		fields = append(fields, "userEnteredFormat.textFormat.foregroundColor")
+	}
+	if style.HorizontalAlignment != "" {
+		fields = append(fields, "userEnteredFormat.horizontalAlignment")
+	}
+	if style.VerticalAlignment != "" {
+		fields = append(fields, "userEnteredFormat.verticalAlignment")
+	}
+	if style.WrapStrategy != "" {
+		fields = append(fields, "userEnteredFormat.wrapStrategy")
+	}
+	return format, fields
+}
+
+func (b *Border) toSheetsBorder() *sheets.Border {
+	if b == nil {
+		return nil
+	}
+	border := &sheets.Border{
+		Style: b.Style,
+	}
+	if b.Color != nil {
+		border.Color = b.Color.toSheetsColor()
+	}
+	return border
+}
@@ -0,0 +1,360 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Transform is one step of the pipeline applied to a table before it is uploaded.
+// Exactly one of its fields is set.
+type Transform struct {
+	// Keeps only the rows satisfying the predicate
+	Filter *Predicate `json:"filter"`
+	// Keeps only these columns, in this order
+	Select []string `json:"select"`
+	// New header by current header
+	Rename  map[string]string `json:"rename"`
+	Sort    []*SortKey        `json:"sort"`
+	GroupBy *GroupBy          `json:"group_by"`
+	Compute *ComputedColumn   `json:"compute"`
+}
+
+// SortKey orders rows by a column, numerically when both values are numbers
+type SortKey struct {
+	Column     string `json:"column"`
+	Descending bool   `json:"descending"`
+}
+
+// GroupBy replaces the rows with one row per distinct combination of Columns, in order of first appearance,
+// followed by the aggregates of each group
+type GroupBy struct {
+	Columns    []string     `json:"columns"`
+	Aggregates []*Aggregate `json:"aggregates"`
+}
+
+// Aggregate is one of sum, average, median, min, max, count (non-empty values) or first of a column
+type Aggregate struct {
+	Column   string `json:"column"`
+	Function string `json:"function"`
+	// Header of the aggregate column. Defaults to function(column)
+	As string `json:"as"`
+}
+
+// ComputedColumn appends a column whose values are an arithmetic expression over the other columns,
+// e.g. ({Current} - {Previous}) / {Previous}. Rows where a referenced value is not a number, or which
+// divide by zero, are left empty.
+type ComputedColumn struct {
+	Header     string `json:"header"`
+	Expression string `json:"expression"`
+}
+
+// Tab is an extra tab holding the uploaded table after its own transforms
+type Tab struct {
+	Title      string       `json:"title"`
+	Transforms []*Transform `json:"transforms"`
+}
+
+// ApplyTransforms runs the transforms in order over a copy of the table, whose first row is its header
+func ApplyTransforms(table [][]string, transforms []*Transform) ([][]string, error) {
+	if len(table) == 0 {
+		return table, nil
+	}
+
+	// copy so that later changes to the result, such as appended columns, do not reach the input
+	copied := make([][]string, len(table))
+	for i, row := range table {
+		copied[i] = append([]string{}, row...)
+	}
+	table = copied
+
+	for _, transform := range transforms {
+		var err error
+		switch {
+		case transform.Filter != nil:
+			table, err = filterRows(table, transform.Filter)
+		case transform.Select != nil:
+			table, err = selectColumns(table, transform.Select)
+		case transform.Rename != nil:
+			table, err = renameColumns(table, transform.Rename)
+		case transform.Sort != nil:
+			table, err = sortRows(table, transform.Sort)
+		case transform.GroupBy != nil:
+			table, err = groupBy(table, transform.GroupBy)
+		case transform.Compute != nil:
+			table, err = computeColumn(table, transform.Compute)
+		default:
+			err = validationErrorf("empty transform")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+func filterRows(table [][]string, predicate *Predicate) ([][]string, error) {
+	rows, err := predicate.MatchingRows(table)
+	if err != nil {
+		return nil, err
+	}
+	result := [][]string{table[0]}
+	for _, row := range rows {
+		result = append(result, table[row])
+	}
+	return result, nil
+}
+
+func selectColumns(table [][]string, columns []string) ([][]string, error) {
+	indices, err := columnIndices(table[0], columns)
+	if err != nil {
+		return nil, err
+	}
+	var result [][]string
+	for _, row := range table {
+		selected := make([]string, len(indices))
+		for i, index := range indices {
+			selected[i] = cell(row, index)
+		}
+		result = append(result, selected)
+	}
+	return result, nil
+}
+
+func renameColumns(table [][]string, names map[string]string) ([][]string, error) {
+	header := append([]string{}, table[0]...)
+	for column, name := range names {
+		index := sliceIndex(table[0], column)
+		if index < 0 {
+			return nil, missingColumnError(column)
+		}
+		header[index] = name
+	}
+	return append([][]string{header}, table[1:]...), nil
+}
+
+func sortRows(table [][]string, keys []*SortKey) ([][]string, error) {
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		columns[i] = key.Column
+	}
+	indices, err := columnIndices(table[0], columns)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := append([][]string{}, table[1:]...)
+	sort.SliceStable(rows, func(i, j int) bool {
+		for k, index := range indices {
+			comparison := compareValues(cell(rows[i], index), cell(rows[j], index))
+			if comparison == 0 {
+				continue
+			}
+			if keys[k].Descending {
+				return comparison > 0
+			}
+			return comparison < 0
+		}
+		return false
+	})
+	return append([][]string{table[0]}, rows...), nil
+}
+
+func groupBy(table [][]string, group *GroupBy) ([][]string, error) {
+	keyIndices, err := columnIndices(table[0], group.Columns)
+	if err != nil {
+		return nil, err
+	}
+	header := append([]string{}, group.Columns...)
+	aggregateIndices := make([]int, len(group.Aggregates))
+	for i, aggregate := range group.Aggregates {
+		if _, ok := aggregateFunctions[strings.ToLower(aggregate.Function)]; !ok {
+			return nil, validationErrorf("unknown aggregate function: %s", aggregate.Function)
+		}
+		aggregateIndices[i] = sliceIndex(table[0], aggregate.Column)
+		if aggregateIndices[i] < 0 {
+			return nil, missingColumnError(aggregate.Column)
+		}
+		name := aggregate.As
+		if name == "" {
+			name = strings.ToLower(aggregate.Function) + "(" + aggregate.Column + ")"
+		}
+		header = append(header, name)
+	}
+
+	// rows of each group, keyed by the group's values joined with a separator which cannot appear in csv text
+	var keys []string
+	groups := make(map[string][][]string)
+	for _, row := range table[1:] {
+		values := make([]string, len(keyIndices))
+		for i, index := range keyIndices {
+			values[i] = cell(row, index)
+		}
+		key := strings.Join(values, "\x00")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	result := [][]string{header}
+	for _, key := range keys {
+		rows := groups[key]
+		resultRow := strings.Split(key, "\x00")
+		if len(keyIndices) == 0 {
+			resultRow = nil
+		}
+		for i, aggregate := range group.Aggregates {
+			values := make([]string, len(rows))
+			for j, row := range rows {
+				values[j] = cell(row, aggregateIndices[i])
+			}
+			resultRow = append(resultRow, aggregateFunctions[strings.ToLower(aggregate.Function)](values))
+		}
+		result = append(result, resultRow)
+	}
+	return result, nil
+}
+
+func computeColumn(table [][]string, column *ComputedColumn) ([][]string, error) {
+	e, err := parseExpression(table[0], column.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	width := len(table[0])
+	result := [][]string{append(append([]string{}, table[0]...), column.Header)}
+	for _, row := range table[1:] {
+		value := ""
+		number, err := e.evaluate(row, false)
+		if err == nil {
+			value = formatNumber(number)
+		}
+		// the computed value goes under its header, before any cells of the row past the header
+		computed := make([]string, width)
+		copy(computed, row)
+		computed = append(computed, value)
+		if len(row) > width {
+			computed = append(computed, row[width:]...)
+		}
+		result = append(result, computed)
+	}
+	return result, nil
+}
+
+var aggregateFunctions = map[string]func(values []string) string{
+	"sum": func(values []string) string {
+		sum := 0.0
+		for _, number := range numbers(values) {
+			sum += number
+		}
+		return formatNumber(sum)
+	},
+	"average": func(values []string) string {
+		numbers := numbers(values)
+		if len(numbers) == 0 {
+			return ""
+		}
+		sum := 0.0
+		for _, number := range numbers {
+			sum += number
+		}
+		return formatNumber(sum / float64(len(numbers)))
+	},
+	"median": func(values []string) string {
+		numbers := numbers(values)
+		if len(numbers) == 0 {
+			return ""
+		}
+		sort.Float64s(numbers)
+		middle := len(numbers) / 2
+		if len(numbers)%2 == 0 {
+			return formatNumber((numbers[middle-1] + numbers[middle]) / 2)
+		}
+		return formatNumber(numbers[middle])
+	},
+	"min": func(values []string) string {
+		numbers := numbers(values)
+		if len(numbers) == 0 {
+			return ""
+		}
+		sort.Float64s(numbers)
+		return formatNumber(numbers[0])
+	},
+	"max": func(values []string) string {
+		numbers := numbers(values)
+		if len(numbers) == 0 {
+			return ""
+		}
+		sort.Float64s(numbers)
+		return formatNumber(numbers[len(numbers)-1])
+	},
+	"count": func(values []string) string {
+		count := 0
+		for _, value := range values {
+			if value != "" {
+				count++
+			}
+		}
+		return strconv.Itoa(count)
+	},
+	"first": func(values []string) string {
+		return values[0]
+	},
+}
+
+// numbers returns the values which parse as numbers, skipping the rest
+func numbers(values []string) []float64 {
+	var result []float64
+	for _, value := range values {
+		if number, ok := parseNumber(value); ok {
+			result = append(result, number)
+		}
+	}
+	return result
+}
+
+func formatNumber(number float64) string {
+	// avoid printing negative zero
+	if number == 0 {
+		number = 0
+	}
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// compareValues orders numbers before text, numbers numerically and text lexically, which is a total order
+func compareValues(a string, b string) int {
+	aNumber, aIsNumber := parseNumber(a)
+	bNumber, bIsNumber := parseNumber(b)
+	switch {
+	case aIsNumber && !bIsNumber:
+		return -1
+	case !aIsNumber && bIsNumber:
+		return 1
+	case !aIsNumber:
+		return strings.Compare(a, b)
+	case aNumber < bNumber:
+		return -1
+	case aNumber > bNumber:
+		return 1
+	}
+	return 0
+}
+
+func columnIndices(header []string, columns []string) ([]int, error) {
+	indices := make([]int, len(columns))
+	for i, column := range columns {
+		indices[i] = sliceIndex(header, column)
+		if indices[i] < 0 {
+			return nil, missingColumnError(column)
+		}
+	}
+	return indices, nil
+}
+
+func cell(row []string, index int) string {
+	if index < len(row) {
+		return row[index]
+	}
+	return ""
+}
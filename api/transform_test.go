@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCompareValuesOrder(t *testing.T) {
+	want := []string{"-1", "2", "10", "10.5", "Inf", "NaN", "abc", "b", "x10"}
+	orders := [][]string{
+		{"b", "10", "NaN", "x10", "2", "abc", "10.5", "Inf", "-1"},
+		{"x10", "Inf", "abc", "10.5", "-1", "b", "NaN", "2", "10"},
+		{"10", "2", "-1", "NaN", "10.5", "x10", "b", "abc", "Inf"},
+	}
+	for _, values := range orders {
+		sort.SliceStable(values, func(i, j int) bool {
+			return compareValues(values[i], values[j]) < 0
+		})
+		for i := range want {
+			if values[i] != want[i] {
+				t.Errorf("got %v, want %v", values, want)
+				break
+			}
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	table := [][]string{
+		{"Region", "Product", "Sales"},
+		{"east", "a", "10"},
+		{"west", "a", "5"},
+		{"east", "b", "20"},
+		{"east", "a", "abc"},
+		{"west", "b", ""},
+		{"east", "c", "NaN"},
+	}
+	got, err := groupBy(table, &GroupBy{
+		Columns: []string{"Region"},
+		Aggregates: []*Aggregate{
+			{Column: "Sales", Function: "SUM"},
+			{Column: "Sales", Function: "average", As: "Mean"},
+			{Column: "Sales", Function: "median"},
+			{Column: "Sales", Function: "min"},
+			{Column: "Sales", Function: "max"},
+			{Column: "Sales", Function: "count"},
+			{Column: "Product", Function: "first"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("groupBy: %s", err.Error())
+	}
+	want := [][]string{
+		{"Region", "sum(Sales)", "Mean", "median(Sales)", "min(Sales)", "max(Sales)", "count(Sales)", "first(Product)"},
+		{"east", "30", "15", "15", "10", "20", "4", "a"},
+		{"west", "5", "5", "5", "5", "5", "1", "a"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, err = groupBy(table, &GroupBy{
+		Aggregates: []*Aggregate{{Column: "Sales", Function: "sum"}},
+	})
+	if err != nil {
+		t.Fatalf("groupBy without columns: %s", err.Error())
+	}
+	want = [][]string{{"sum(Sales)"}, {"35"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	_, err = groupBy(table, &GroupBy{Columns: []string{"Missing"}})
+	if !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("got %v, want ErrMissingColumn", err)
+	}
+	_, err = groupBy(table, &GroupBy{
+		Aggregates: []*Aggregate{{Column: "Sales", Function: "mode"}},
+	})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("got %v, want ErrValidation", err)
+	}
+}
+
+func TestComputeColumn(t *testing.T) {
+	table := [][]string{
+		{"Name", "Current", "Previous"},
+		{"a", "15", "10"},
+		{"b", "1,000", "10"},
+		{"c", "5", "0"},
+		{"d", "Infinity", "1"},
+		{"e", "7"},
+		{},
+		{"f", "3", "1", "extra"},
+	}
+	got, err := computeColumn(table, &ComputedColumn{
+		Header:     "Change",
+		Expression: "({Current} - {Previous}) / {Previous}",
+	})
+	if err != nil {
+		t.Fatalf("computeColumn: %s", err.Error())
+	}
+	want := [][]string{
+		{"Name", "Current", "Previous", "Change"},
+		{"a", "15", "10", "0.5"},
+		{"b", "1,000", "10", ""},
+		{"c", "5", "0", ""},
+		{"d", "Infinity", "1", ""},
+		{"e", "7", "", ""},
+		{"", "", "", ""},
+		{"f", "3", "1", "2", "extra"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	_, err = computeColumn(table, &ComputedColumn{Header: "Bad", Expression: "{Current} / 0 +"})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("got %v, want ErrValidation", err)
+	}
+}
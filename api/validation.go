@@ -0,0 +1,96 @@
+package api
+
+import (
+	"google.golang.org/api/sheets/v4"
+)
+
+// ValidationRule restricts the values which can be entered into cells
+type ValidationRule struct {
+	// list, checkbox, number_between, number_greater, number_less,
+	// date_between, date_before, date_after or custom
+	Type string `json:"type"`
+	// Allowed values for list, bounds for number and date rules,
+	// checked and unchecked values for checkbox and the formula for custom, e.g. =LEN(E2)<100
+	Values []string `json:"values"`
+	// Rejects invalid input instead of only flagging it
+	Strict       bool   `json:"strict"`
+	InputMessage string `json:"input_message"`
+}
+
+// Validation applies a rule to the data rows of a column, or to a range in A1 notation
+type Validation struct {
+	ValidationRule
+	Column string `json:"column"`
+	Range  string `json:"range"`
+	// Appends Column as an empty column after the table when it is not one of the table's columns
+	AddColumn bool `json:"add_column"`
+}
+
+var validationConditions = map[string]struct {
+	conditionType string
+	minValues     int
+	maxValues     int
+}{
+	"list":           {"ONE_OF_LIST", 1, -1},
+	"checkbox":       {"BOOLEAN", 0, 2},
+	"number_between": {"NUMBER_BETWEEN", 2, 2},
+	"number_greater": {"NUMBER_GREATER", 1, 1},
+	"number_less":    {"NUMBER_LESS", 1, 1},
+	"date_between":   {"DATE_BETWEEN", 2, 2},
+	"date_before":    {"DATE_BEFORE", 1, 1},
+	"date_after":     {"DATE_AFTER", 1, 1},
+	"custom":         {"CUSTOM_FORMULA", 1, 1},
+}
+
+func (s *Service) SetDataValidation(spreadsheetId string, startPosition *CellPosition, endPosition *CellPosition, rule *ValidationRule) error {
+	dataValidationRule, err := rule.toDataValidationRule()
+	if err != nil {
+		return err
+	}
+
+	sheetId, err := s.GetFirstSheetId(spreadsheetId)
+	if err != nil {
+		return err
+	}
+
+	request := &sheets.Request{
+		SetDataValidation: &sheets.SetDataValidationRequest{
+			Range: toGridRange(sheetId, startPosition, endPosition),
+			Rule:  dataValidationRule,
+		},
+	}
+
+	_, err = s.sheetsService().Spreadsheets.BatchUpdate(spreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			request,
+		},
+	}).Do()
+	return s.sheetsError(err, spreadsheetId, "")
+}
+
+func (r *ValidationRule) toDataValidationRule() (*sheets.DataValidationRule, error) {
+	condition, ok := validationConditions[r.Type]
+	if !ok {
+		return nil, validationErrorf("unknown validation type: %s", r.Type)
+	}
+	if len(r.Values) < condition.minValues || (condition.maxValues >= 0 && len(r.Values) > condition.maxValues) {
+		return nil, validationErrorf("wrong number of values for %s validation: %d", r.Type, len(r.Values))
+	}
+
+	var values []*sheets.ConditionValue
+	for _, value := range r.Values {
+		values = append(values, &sheets.ConditionValue{
+			UserEnteredValue: value,
+		})
+	}
+
+	return &sheets.DataValidationRule{
+		Condition: &sheets.BooleanCondition{
+			Type:   condition.conditionType,
+			Values: values,
+		},
+		InputMessage: r.InputMessage,
+		ShowCustomUi: r.Type == "list",
+		Strict:       r.Strict,
+	}, nil
+}
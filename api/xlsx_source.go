@@ -0,0 +1,77 @@
+package api
+
+import (
+	"github.com/xuri/excelize/v2"
+	"io"
+)
+
+type xlsxSource struct {
+	file  *excelize.File
+	rows  *excelize.Rows
+	width int
+}
+
+// NewXlsxSource reads the formatted cell values of a sheet of the workbook read from reader.
+// The first sheet is read when sheet is empty.
+func NewXlsxSource(reader io.Reader, sheet string) (TableSource, error) {
+	file, err := excelize.OpenReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if sheet == "" {
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			file.Close()
+			return nil, &Error{
+				Kind: ErrNotFound,
+				Err:  errNoSheets,
+			}
+		}
+		sheet = sheets[0]
+	} else if sliceIndex(file.GetSheetList(), sheet) < 0 {
+		file.Close()
+		return nil, &Error{
+			Kind:  ErrNotFound,
+			Range: sheet,
+		}
+	}
+
+	rows, err := file.Rows(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxSource{
+		file: file,
+		rows: rows,
+	}, nil
+}
+
+func (x *xlsxSource) Next() ([]string, error) {
+	if !x.rows.Next() {
+		err := x.rows.Error()
+		if err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	row, err := x.rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	// trailing empty cells are omitted, so pad rows to the width of the header
+	if x.width == 0 {
+		x.width = len(row)
+	}
+	for len(row) < x.width {
+		row = append(row, "")
+	}
+	return row, nil
+}
+
+func (x *xlsxSource) Close() error {
+	x.rows.Close()
+	return x.file.Close()
+}
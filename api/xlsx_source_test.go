@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"github.com/xuri/excelize/v2"
+	"reflect"
+	"testing"
+)
+
+// testWorkbook returns a workbook with the given sheets, whose cells are set by A1 reference
+func testWorkbook(t *testing.T, sheets map[string]map[string]interface{}, order []string) *bytes.Buffer {
+	file := excelize.NewFile()
+	defer file.Close()
+	for i, sheet := range order {
+		if i == 0 {
+			file.SetSheetName("Sheet1", sheet)
+		} else if _, err := file.NewSheet(sheet); err != nil {
+			t.Fatalf("NewSheet: %s", err.Error())
+		}
+		for reference, value := range sheets[sheet] {
+			if err := file.SetCellValue(sheet, reference, value); err != nil {
+				t.Fatalf("SetCellValue: %s", err.Error())
+			}
+		}
+	}
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		t.Fatalf("Write: %s", err.Error())
+	}
+	return &buffer
+}
+
+func TestXlsxSource(t *testing.T) {
+	sheets := map[string]map[string]interface{}{
+		"Results": {
+			"A1": "name", "B1": "score", "C1": "note",
+			"A2": "alpha",
+			"A3": "beta", "B3": 2.5,
+			"A4": "gamma", "B4": 3, "C4": "x",
+		},
+		"Other": {
+			"A1": "id", "A2": 1,
+		},
+	}
+	tests := []struct {
+		name  string
+		sheet string
+		want  [][]string
+	}{
+		{
+			name:  "first sheet with ragged rows",
+			sheet: "",
+			want: [][]string{
+				{"name", "score", "note"},
+				{"alpha", "", ""},
+				{"beta", "2.5", ""},
+				{"gamma", "3", "x"},
+			},
+		},
+		{
+			name:  "named sheet",
+			sheet: "Other",
+			want:  [][]string{{"id"}, {"1"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, err := NewXlsxSource(testWorkbook(t, sheets, []string{"Results", "Other"}), test.sheet)
+			if err != nil {
+				t.Fatalf("NewXlsxSource: %s", err.Error())
+			}
+			table, err := ReadAll(source)
+			if err != nil {
+				t.Fatalf("ReadAll: %s", err.Error())
+			}
+			if !reflect.DeepEqual(table, test.want) {
+				t.Errorf("got %q, want %q", table, test.want)
+			}
+		})
+	}
+
+	_, err := NewXlsxSource(testWorkbook(t, sheets, []string{"Results"}), "Missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
@@ -2,13 +2,15 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
 	"errors"
 	"flag"
 	"fmt"
 	"github.com/yuhongherald/google-sheet-go/api"
+	"google.golang.org/api/googleapi"
 	"log"
 	"math"
+	_ "modernc.org/sqlite"
+	"net/http"
 	"os"
 	"sort"
 	"strconv"
@@ -17,13 +19,63 @@ import (
 
 func main() {
 	titleP := flag.String("title", "title", "Title of Google Sheet")
-	googleCredentialsP := flag.String("google-credentials", "{}", "Google credentials JSON string")
-	contentFileP := flag.String("content-file", "", "Table contents to be uploaded, in csv format")
+	googleCredentialsP := flag.String("google-credentials", "", "Google credentials JSON string. Prefer --google-credentials-file or --google-credentials-env, which keep it out of the process list")
+	googleCredentialsFileP := flag.String("google-credentials-file", "", "File of Google credentials JSON: a service account key, authorized user or OAuth client")
+	googleCredentialsEnvP := flag.String("google-credentials-env", "", "Environment variable holding Google credentials JSON")
+	googleTokenFileP := flag.String("google-token-file", "token.json", "Token cache of the OAuth sign in, used with OAuth client credentials")
+	googleSubjectP := flag.String("google-subject", "", "User impersonated by the service account with domain-wide delegation")
+	googleImpersonateP := flag.String("google-impersonate", "", "Service account impersonated by the credentials")
+	contentFileP := flag.String("content-file", "", "Table contents to be uploaded, or - for stdin")
+	contentFormatP := flag.String("content-format", "", "csv, tsv, json, jsonl, xlsx or parquet. Defaults to the format of the file extension, or csv")
+	delimiterP := flag.String("delimiter", "", "Field delimiter of csv. Defaults to , for csv and tab for tsv")
+	commentP := flag.String("comment", "", "Skip csv lines starting with this character")
+	lazyQuotesP := flag.Bool("lazy-quotes", false, "Allow unescaped quotes in csv fields")
+	noHeaderP := flag.Bool("no-header", false, "The first csv row is data, and the columns are named A, B, C...")
+	xlsxSheetP := flag.String("xlsx-sheet", "", "Sheet of xlsx to read. Defaults to the first sheet")
+	sqlDriverP := flag.String("sql-driver", "", "database/sql driver of --sql-dsn. Defaults to sqlite")
+	sqlDsnP := flag.String("sql-dsn", "", "Data source name of the database queried by --sql-query, e.g. the sqlite file")
+	sqlQueryP := flag.String("sql-query", "", "SQL query whose result is uploaded instead of --content-file")
+	streamP := flag.Bool("stream", false, "Upload the table in chunks while reading it, for tables too large to hold in memory")
+	chunkBytesP := flag.Int("chunk-bytes", 2<<20, "Approximate size of the values uploaded by one request when streaming")
+	parallelismP := flag.Int("parallelism", 1, "Number of chunks uploaded at the same time when streaming")
 	chartFileP := flag.String("chart-file", "chart.json", "list of graph config objects")
-	usersP := flag.String("users", "", "comma separated emails")
+	usersP := flag.String("users", "", "Comma separated [type:]target[:role] entries, e.g. a@x.com:reader,group:team@x.com,domain:x.com:commenter,anyone:reader. The type defaults to user and the role to writer")
+	shareMessageP := flag.String("share-message", "", "Message added to the email Drive sends to shared users and groups")
+	noNotifyP := flag.Bool("no-notify", false, "Share without the email Drive sends to users and groups")
+	syncPermissionsP := flag.Bool("sync-permissions", false, "Make the permissions of the spreadsheet exactly --users and the permissions of the report spec, removing all others except owners, inherited permissions and the running account. The plan is logged first")
+	syncDryRunP := flag.Bool("sync-dry-run", false, "Log the plan of --sync-permissions and exit without changing the spreadsheet or its permissions")
+	revokeP := flag.String("revoke", "", "Comma separated [type:]target entries whose permissions are removed, e.g. old@x.com,anyone")
 	sendEmailMessageP := flag.String("send-email-message", "", "Sender email. Leave this blank to not send email")
+	emailTemplateP := flag.String("email-template", "", "html/template file of the email body. Defaults to a summary of the table")
+	emailAttachP := flag.String("email-attach", "", "Attach the spreadsheet exported as pdf, xlsx or csv to the email, unless it is too large")
+	emailCcP := flag.String("email-cc", "", "Comma separated emails copied on the email")
+	emailBccP := flag.String("email-bcc", "", "Comma separated emails blind copied on the email")
+	emailReplyToP := flag.String("email-reply-to", "", "Reply-To address of the email")
 	highlightColumnsP := flag.String("highlight-columns", "", "Comma separated column names")
 	spreadsheetIdP := flag.String("google-sheet-id", "", "Google Sheet id of existing spreadsheet: https://docs.google.com/spreadsheets/d/<id>/...")
+	folderP := flag.String("folder", "", "Drive folder id, or shared drive id, holding the spreadsheet")
+	sharedDriveP := flag.String("shared-drive", "", "Id of the shared drive holding the spreadsheet, or holding --folder")
+	folderPathP := flag.String("folder-path", "", "Path of folders under --folder, e.g. Reports/Weekly, created if missing")
+	descriptionP := flag.String("description", "", "Drive description of the spreadsheet")
+	propertiesP := flag.String("properties", "", "Comma separated key=value custom Drive properties of the spreadsheet")
+	tagP := flag.String("tag", "", "Private Drive property identifying the spreadsheet for --find")
+	findP := flag.Bool("find", false, "Reuse the spreadsheet with the same title, folder and --tag instead of --google-sheet-id, creating it when missing")
+	ownerP := flag.String("owner", "", "Email of the user given ownership of the spreadsheet")
+	reportFileP := flag.String("report-file", "", "Report spec JSON file. Leave blank to only use the formatting flags")
+	highlightRowsP := flag.String("highlight-rows", "", "Comma separated row predicates, e.g. status==FAIL,regression>10%")
+	bandingP := flag.Bool("banding", false, "Color alternating rows of the table")
+	styleP := flag.String("style", "none", "Style preset: none, or report (bold, bordered header)")
+	freezeRowsP := flag.Int("freeze-rows", 0, "Number of rows to freeze, e.g. 1 for the header row")
+	freezeColumnsP := flag.Int("freeze-columns", 0, "Number of columns to freeze")
+	autoResizeP := flag.Bool("auto-resize", false, "Fit column widths to their contents")
+	filterP := flag.Bool("filter", false, "Add a filter to the header row of the table")
+	archiveP := flag.String("archive", "", "Keep the previous report of --google-sheet-id: tab duplicates it into a dated tab, copy copies the spreadsheet")
+	archiveFolderP := flag.String("archive-folder", "", "Drive folder id of archive copies. Defaults to the folder of the spreadsheet")
+	archiveKeepP := flag.Int("archive-keep", 0, "Number of archives kept, pruning the oldest. 0 keeps every archive")
+	diffKeyP := flag.String("diff-key", "", "Key column matching rows with the previous contents of --google-sheet-id, to highlight changed cells")
+	logLevelP := flag.String("log-level", "info", "Log messages of this level and above: debug, info, warn or error. debug logs every request")
+	logFormatP := flag.String("log-format", "text", "Log format: text or json")
+	summaryFileP := flag.String("summary-file", "", "JSON file written with the status, the duration and the requests of the run and of each step")
 	flag.Parse()
 
 	args := os.Args
@@ -49,88 +101,408 @@ func main() {
 		return
 	}
 
+	logger, err := api.NewLogger(os.Stderr, *logFormatP, *logLevelP)
+	if err != nil {
+		log.Fatalf("failed to configure logging: %s", err.Error())
+	}
+	r := newRun(logger, *summaryFileP)
+
 	title := *titleP
-	googleCredentials := *googleCredentialsP
+	credentials := &api.Credentials{
+		JSON:                      []byte(*googleCredentialsP),
+		File:                      *googleCredentialsFileP,
+		Env:                       *googleCredentialsEnvP,
+		TokenFile:                 *googleTokenFileP,
+		Subject:                   *googleSubjectP,
+		ImpersonateServiceAccount: *googleImpersonateP,
+	}
 	contentFile := *contentFileP
+	sourceOptions := &api.SourceOptions{
+		Format:     *contentFormatP,
+		Delimiter:  firstRune(*delimiterP),
+		Comment:    firstRune(*commentP),
+		LazyQuotes: *lazyQuotesP,
+		NoHeader:   *noHeaderP,
+		Sheet:      *xlsxSheetP,
+	}
+	stream := *streamP
+	streamOptions := &api.StreamOptions{
+		ChunkBytes:  *chunkBytesP,
+		Parallelism: *parallelismP,
+		Progress: func(rows int) {
+			logger.Info("uploaded rows", "rows", rows)
+		},
+	}
 	chartFile := *chartFileP
 	users := *usersP
+	shareOptions := &api.ShareOptions{
+		Message:              *shareMessageP,
+		SuppressNotification: *noNotifyP,
+	}
+	revoke := *revokeP
+	syncDryRun := *syncDryRunP
+	syncPermissions := *syncPermissionsP || syncDryRun
 	highlightColumns := *highlightColumnsP
 	sendEmailMessage := *sendEmailMessageP
+	email := &api.Email{
+		Cc:      splitList(*emailCcP),
+		Bcc:     splitList(*emailBccP),
+		ReplyTo: *emailReplyToP,
+	}
 	spreadsheetId := *spreadsheetIdP
+	fileOptions := &api.FileOptions{
+		Folder:      *folderP,
+		SharedDrive: *sharedDriveP,
+		FolderPath:  *folderPathP,
+		Description: *descriptionP,
+		Tag:         *tagP,
+	}
+	find := *findP
+	owner := *ownerP
+	reportFile := *reportFileP
+	flags := &reportFlags{
+		highlightRows: *highlightRowsP,
+		banding:       *bandingP,
+		style:         *styleP,
+		freezeRows:    *freezeRowsP,
+		freezeColumns: *freezeColumnsP,
+		autoResize:    *autoResizeP,
+		filter:        *filterP,
+		diffKey:       *diffKeyP,
+		archive:       *archiveP,
+		archiveFolder: *archiveFolderP,
+		archiveKeep:   *archiveKeepP,
+		emailTemplate: *emailTemplateP,
+		emailAttach:   *emailAttachP,
+		sqlDriver:     *sqlDriverP,
+		sqlDsn:        *sqlDsnP,
+		sqlQuery:      *sqlQueryP,
+	}
 
-	var userList []string
-	if users != "" {
-		userList = strings.Split(users, ",")
+	properties, err := parseProperties(*propertiesP)
+	if err != nil {
+		r.fatalf("failed to parse properties: %s", describe(err))
+	}
+	fileOptions.Properties = properties
+	permissions, err := parsePermissions(users)
+	if err != nil {
+		r.fatalf("failed to parse users: %s", describe(err))
+	}
+	revokedPermissions, err := parsePermissions(revoke)
+	if err != nil {
+		r.fatalf("failed to parse revoked users: %s", describe(err))
+	}
+	if syncPermissions && len(revokedPermissions) > 0 {
+		r.fatalf("--revoke cannot be used with --sync-permissions, which removes every permission missing from --users")
 	}
 
-	table, err := readCsv(contentFile)
+	report, err := readReport(reportFile, flags)
 	if err != nil {
-		log.Fatalf("failed to read csv")
+		r.fatalf("failed to read report spec: %s", describe(err))
 	}
-	if len(table) == 0 {
-		log.Fatalf("empty csv file")
+
+	r.step("read table")
+	var source api.TableSource
+	if report.Sql != nil {
+		source, err = report.Sql.Open()
+	} else {
+		source, err = api.OpenTableSource(contentFile, sourceOptions)
+	}
+	if err != nil {
+		r.fatalf("failed to open table: %s", describe(err))
+	}
+	// text values of the source are quoted when they are written, and kept as they are for the report and email
+	textColumns := api.TextColumns(source)
+
+	var table [][]string
+	// the table as read and transformed, without the formula and validation columns, whose cells are not values
+	var emailTable [][]string
+	var tabTables [][][]string
+	if stream {
+		err = checkStreamable(report, highlightColumns)
+		if err != nil {
+			r.fatalf("failed to stream table: %s", describe(err))
+		}
+	} else {
+		table, err = api.ReadAll(source)
+		if err != nil {
+			r.fatalf("failed to read table: %s", describe(err))
+		}
+		if len(table) == 0 {
+			r.fatalf("empty table")
+		}
+
+		tabTables, err = transformTabs(table, report.Tabs)
+		if err != nil {
+			r.fatalf("failed to transform table: %s", describe(err))
+		}
+		table, err = api.ApplyTransforms(table, report.Transforms)
+		if err != nil {
+			r.fatalf("failed to transform table: %s", describe(err))
+		}
+		// AddFormulaColumns appends to the rows of table, so the email keeps its own slice of them
+		emailTable = append([][]string(nil), table...)
+		table, err = api.AddFormulaColumns(table, api.Origin(), report.FormulaColumns)
+		if err != nil {
+			r.fatalf("failed to add formula columns: %s", describe(err))
+		}
+		table = addValidationColumns(table, report.Validations)
+	}
+
+	placed := fileOptions.Folder != "" || fileOptions.SharedDrive != "" || fileOptions.FolderPath != "" ||
+		fileOptions.Description != "" || len(fileOptions.Properties) > 0 || fileOptions.Tag != ""
+	emailOptions := report.Email
+	if emailOptions == nil {
+		emailOptions = &api.EmailOptions{}
+	}
+	notifierConfig := report.Notifier
+	if notifierConfig == nil {
+		notifierConfig = &api.NotifierConfig{}
+	}
+
+	// request only the scopes of the configured operations. Files which the service did not create,
+	// like existing spreadsheets and folders, need the full Drive scope
+	credentials.Scopes = []string{api.SheetsScope}
+	if spreadsheetId != "" || find || fileOptions.Folder != "" || fileOptions.SharedDrive != "" || fileOptions.FolderPath != "" {
+		credentials.Scopes = append(credentials.Scopes, api.DriveScope)
+	} else if placed || len(permissions) > 0 || len(report.Permissions) > 0 || len(revokedPermissions) > 0 ||
+		syncPermissions || owner != "" || (sendEmailMessage != "" && emailOptions.Attach != "") {
+		credentials.Scopes = append(credentials.Scopes, api.DriveFileScope)
+	}
+	if sendEmailMessage != "" && (notifierConfig.Type == "" || notifierConfig.Type == "gmail") {
+		credentials.Scopes = append(credentials.Scopes, api.GmailSendScope)
 	}
 
 	ctx := context.Background()
 
-	service, err := api.NewService(ctx, []byte(googleCredentials))
+	r.step("start service")
+	service, err := api.NewServiceWithCredentials(ctx, credentials)
 	if err != nil {
-		log.Fatalf("failed to start service: %s", err.Error())
+		r.fatalf("failed to start service: %s", describe(err))
+	}
+	service.SetLogger(logger)
+	r.service = service
+
+	if find && spreadsheetId == "" {
+		r.step("find spreadsheet")
+		spreadsheetId, err = service.FindSpreadsheet(title, fileOptions)
+		if err != nil {
+			r.fatalf("failed to find spreadsheet: %s", describe(err))
+		}
+	}
+
+	// a dry run only logs the permission plan, leaving the spreadsheet as it is
+	if syncDryRun {
+		r.step("plan permissions")
+		if spreadsheetId != "" {
+			r.setSpreadsheet(spreadsheetId)
+		}
+		_, err = planPermissionSync(logger, service, spreadsheetId, append(report.Permissions, permissions...))
+		if err != nil {
+			r.fatalf("failed to plan permissions: %s", describe(err))
+		}
+		r.finish(nil)
+		return
+	}
+
+	var previous [][]string
+	if spreadsheetId != "" && report.Diff != nil {
+		r.step("read previous table")
+		previous, err = service.ReadSheet(spreadsheetId, "Sheet1")
+		if err != nil {
+			r.fatalf("failed to read previous table: %s", describeSpreadsheet(err))
+		}
+		// the summary row below the previous table is not a table row
+		if report.Summary != nil && len(previous) > 1 {
+			previous = previous[:len(previous)-1]
+		}
+	}
+
+	if spreadsheetId != "" && report.Archive != nil {
+		r.step("archive previous report")
+		err = archivePrevious(logger, service, spreadsheetId, report.Archive)
+		if err != nil {
+			r.fatalf("failed to archive previous report: %s", describe(err))
+		}
 	}
 
+	r.step("create spreadsheet")
 	if spreadsheetId != "" {
 		err = service.Recreate(spreadsheetId, title)
 		if err != nil {
-			log.Fatalf("failed to recreate spreadsheet: %s", err.Error())
+			r.fatalf("failed to recreate spreadsheet: %s", describeSpreadsheet(err))
+		}
+		if placed {
+			err = service.UpdateFile(spreadsheetId, fileOptions)
+			if err != nil {
+				r.fatalf("failed to update spreadsheet file: %s", describe(err))
+			}
+		}
+	} else if placed {
+		spreadsheetId, err = service.CreateInFolder(title, fileOptions)
+		if err != nil {
+			r.fatalf("failed to create new spreadsheet: %s", describe(err))
 		}
 	} else {
 		spreadsheetId, err = service.Create(title)
 		if err != nil {
-			log.Fatalf("failed to create new spreadsheet: %s", err.Error())
+			r.fatalf("failed to create new spreadsheet: %s", describe(err))
 		}
 	}
 
 	fmt.Println("https://docs.google.com/spreadsheets/d/" + spreadsheetId)
+	r.setSpreadsheet(spreadsheetId)
 
-	for _, user := range userList {
-		err = service.Share(spreadsheetId, user)
+	r.step("share")
+	permissions = append(report.Permissions, permissions...)
+	if syncPermissions {
+		err = applyPermissionSync(logger, service, spreadsheetId, permissions, shareOptions)
 		if err != nil {
-			log.Fatalf("failed to share spreadsheet with user %s: %s", user, err.Error())
+			r.fatalf("failed to sync permissions: %s", describe(err))
+		}
+	} else {
+		err = revokePermissions(service, spreadsheetId, revokedPermissions)
+		if err != nil {
+			r.fatalf("failed to revoke permissions: %s", describe(err))
+		}
+		for _, permission := range permissions {
+			err = service.SharePermission(spreadsheetId, permission, shareOptions)
+			if err != nil {
+				r.fatalf("failed to share spreadsheet with %s %s%s: %s", permission.Type, permission.EmailAddress, permission.Domain, describe(err))
+			}
+		}
+	}
+
+	r.step("insert table")
+	var header []string
+	var height int
+	if stream {
+		header, height, err = service.InsertTableFromSource(spreadsheetId, api.Origin(), source, streamOptions)
+		if err != nil {
+			r.fatalf("failed to insert table: %s", describe(err))
+		}
+		width := len(header)
+		header = validationHeader(header, report.Validations)
+		if len(header) > width {
+			err = service.InsertTable(spreadsheetId, api.Origin().Offset(0, width), [][]string{header[width:]})
+			if err != nil {
+				r.fatalf("failed to insert validation columns: %s", describe(err))
+			}
+		}
+	} else {
+		err = service.InsertTable(spreadsheetId, api.Origin(), api.QuoteText(table, textColumns))
+		if err != nil {
+			r.fatalf("failed to insert table: %s", describe(err))
+		}
+		header, height = table[0], len(table)
+
+		err = highlightRows(service, spreadsheetId, table, report.RowHighlights)
+		if err != nil {
+			r.fatalf("failed to apply report: %s", describe(err))
+		}
+	}
+
+	r.step("apply report")
+	err = applyReport(service, spreadsheetId, header, height, report)
+	if err != nil {
+		r.fatalf("failed to apply report: %s", describe(err))
+	}
+
+	if report.Diff != nil {
+		r.step("diff")
+		err = applyDiff(service, spreadsheetId, previous, table, report.Diff, textColumns)
+		if err != nil {
+			r.fatalf("failed to diff with previous table: %s", describe(err))
 		}
 	}
 
-	err = service.InsertTable(spreadsheetId, api.Origin(), table)
+	if len(report.Tabs) > 0 {
+		r.step("insert tabs")
+	}
+	err = insertTabs(service, spreadsheetId, report.Tabs, tabTables, textColumns)
 	if err != nil {
-		log.Fatalf("failed to insert table: %s", err.Error())
+		r.fatalf("failed to insert tabs: %s", describe(err))
 	}
 
 	highlightColumnsList := strings.Split(highlightColumns, ",")
 	if highlightColumns != "" {
+		r.step("highlight columns")
 		for _, highlightColumn := range highlightColumnsList {
 			err = percentileHighlightColumn(service, spreadsheetId, table, highlightColumn)
 			if err != nil {
-				log.Fatalf("failed to highlight column %s: %s", highlightColumn, err.Error())
+				r.fatalf("failed to highlight column %s: %s", highlightColumn, describe(err))
 			}
 		}
 	}
 
+	r.step("add charts")
 	charts, err := api.ReadFromFile(chartFile)
 	if err != nil {
-		log.Fatalf("failed to read chart config %s", err.Error())
+		r.fatalf("failed to read chart config %s", describe(err))
 	}
 	for _, chart := range charts {
 		err = service.AddChart(spreadsheetId, chart)
 		if err != nil {
-			log.Fatalf("failed to add chart %s: %s", chart.Title, err.Error())
+			r.fatalf("failed to add chart %s: %s", chart.Title, describe(err))
+		}
+	}
+
+	if report.Summary != nil {
+		r.step("add summary row")
+		err = addSummaryRow(service, spreadsheetId, header, height, report.Summary)
+		if err != nil {
+			r.fatalf("failed to add summary row: %s", describe(err))
+		}
+	}
+
+	if owner != "" {
+		r.step("transfer ownership")
+		err = service.TransferOwnership(spreadsheetId, owner)
+		if err != nil {
+			r.fatalf("failed to transfer ownership to %s: %s", owner, describe(err))
 		}
 	}
 
 	if sendEmailMessage != "" {
-		err = service.SendEmail(users, title, "Document link: https://docs.google.com/spreadsheets/d/"+spreadsheetId+"\n\n"+sendEmailMessage)
+		r.step("send email")
+		link := "https://docs.google.com/spreadsheets/d/" + spreadsheetId
+		if stream {
+			logger.Warn("streamed tables are not held in memory, so the email has no table")
+		}
+		html, err := api.RenderEmail(emailOptions.Template, api.NewEmailData(title, link, sendEmailMessage, emailTable, emailOptions.TopRows, charts))
+		if err != nil {
+			r.fatalf("failed to render email: %s", describe(err))
+		}
+		email.To = splitList(recipients(permissions))
+		email.Subject = title
+		email.Text = "Document link: " + link + "\n\n" + sendEmailMessage
+		email.HTML = html
+		if emailOptions.Attach != "" {
+			maxAttachmentBytes := emailOptions.MaxAttachmentBytes
+			if maxAttachmentBytes <= 0 {
+				maxAttachmentBytes = 10 << 20
+			}
+			attachment, err := service.ExportAttachment(spreadsheetId, title, emailOptions.Attach, maxAttachmentBytes)
+			if errors.Is(err, api.ErrExportTooLarge) {
+				logger.Warn("export is too large, sending the link only", "format", emailOptions.Attach, "max_bytes", maxAttachmentBytes)
+			} else if err != nil {
+				r.fatalf("failed to export spreadsheet: %s", describe(err))
+			} else {
+				email.Attachments = append(email.Attachments, attachment)
+			}
+		}
+
+		notifier, err := api.NewNotifier(notifierConfig, service)
+		if err != nil {
+			r.fatalf("failed to configure notifier: %s", describe(err))
+		}
+		err = notifier.Notify(email)
 		if err != nil {
-			log.Fatalf("failed to send email: %s", err.Error())
+			r.fatalf("failed to send email: %s", describe(err))
 		}
 	}
+
+	r.finish(nil)
 }
 
 func percentileHighlightColumn(service *api.Service, spreadsheetId string, table [][]string, columnName string) error {
@@ -159,7 +531,7 @@ func percentileHighlightColumn(service *api.Service, spreadsheetId string, table
 
 	index := sliceIndex(table[0], columnName)
 	if index < 0 {
-		return errors.New("missing column: " + columnName)
+		return missingColumnError(columnName)
 	}
 	var negativeValues []float64
 	var positiveValues []float64
@@ -210,6 +582,37 @@ func percentileHighlightColumn(service *api.Service, spreadsheetId string, table
 	return nil
 }
 
+// describe returns the message of err, with a hint for the kinds of failure the user can act on
+func describe(err error) string {
+	var missingScope *api.MissingScopeError
+	switch {
+	case errors.As(err, &missingScope):
+		return err.Error()
+	case errors.Is(err, api.ErrPermissionDenied):
+		return err.Error() + "; share the spreadsheet or folder with the account of the credentials"
+	case errors.Is(err, api.ErrQuotaExceeded):
+		return err.Error() + "; retry later, or lower --parallelism"
+	}
+	return err.Error()
+}
+
+// describeSpreadsheet describes the error of reading or recreating the spreadsheet of --google-sheet-id,
+// pointing at the flag when the spreadsheet does not exist
+func describeSpreadsheet(err error) string {
+	var apiErr *googleapi.Error
+	if errors.Is(err, api.ErrNotFound) && errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return "spreadsheet does not exist; check --google-sheet-id: " + err.Error()
+	}
+	return describe(err)
+}
+
+func missingColumnError(column string) error {
+	return &api.Error{
+		Kind:   api.ErrMissingColumn,
+		Column: column,
+	}
+}
+
 func sliceIndex(slice []string, value string) int {
 	for index, element := range slice {
 		if element == value {
@@ -219,15 +622,37 @@ func sliceIndex(slice []string, value string) int {
 	return -1
 }
 
-func readCsv(file string) ([][]string, error) {
-	f, err := os.Open(file)
-	if err != nil {
-		return nil, err
+// parseProperties parses comma separated key=value pairs
+func parseProperties(pairs string) (map[string]string, error) {
+	properties := make(map[string]string)
+	if pairs == "" {
+		return properties, nil
+	}
+	for _, pair := range strings.Split(pairs, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, errors.New("invalid property: " + pair)
+		}
+		properties[key] = value
+	}
+	return properties, nil
+}
+
+// splitList splits comma separated values, returning none for an empty string
+func splitList(values string) []string {
+	if values == "" {
+		return nil
 	}
-	defer f.Close()
+	return strings.Split(values, ",")
+}
 
-	// read csv values using csv.Reader
-	csvReader := csv.NewReader(f)
-	data, err := csvReader.ReadAll()
-	return data, err
+// firstRune returns the first character of value, treating \t as a tab
+func firstRune(value string) rune {
+	if value == `\t` {
+		return '\t'
+	}
+	for _, r := range value {
+		return r
+	}
+	return 0
 }
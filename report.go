@@ -0,0 +1,397 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yuhongherald/google-sheet-go/api"
+	"strings"
+	"time"
+)
+
+var defaultRowHighlightColor = &api.Color{
+	R: 1,
+	G: 0.8,
+	B: 0.4,
+	A: 1,
+}
+
+// reportFlags are command line shorthands for fields of the report spec
+type reportFlags struct {
+	highlightRows string
+	banding       bool
+	style         string
+	freezeRows    int
+	freezeColumns int
+	autoResize    bool
+	filter        bool
+	diffKey       string
+	archive       string
+	archiveFolder string
+	archiveKeep   int
+	emailTemplate string
+	emailAttach   string
+	sqlDriver     string
+	sqlDsn        string
+	sqlQuery      string
+}
+
+// readReport loads the report spec, if any, and merges in the formatting given as flags
+func readReport(reportFile string, flags *reportFlags) (*api.Report, error) {
+	report := &api.Report{}
+	if reportFile != "" {
+		var err error
+		report, err = api.ReadReportFromFile(reportFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if flags.highlightRows != "" {
+		for _, expression := range strings.Split(flags.highlightRows, ",") {
+			predicate, err := api.ParsePredicate(expression)
+			if err != nil {
+				return nil, err
+			}
+			report.RowHighlights = append(report.RowHighlights, &api.RowHighlight{
+				Predicate: *predicate,
+			})
+		}
+	}
+
+	if flags.banding && report.Banding == nil {
+		report.Banding = api.DefaultBanding()
+	}
+	if flags.freezeRows > 0 {
+		report.FreezeRows = flags.freezeRows
+	}
+	if flags.freezeColumns > 0 {
+		report.FreezeColumns = flags.freezeColumns
+	}
+	report.AutoResize = report.AutoResize || flags.autoResize
+	report.BasicFilter = report.BasicFilter || flags.filter
+	if flags.archive != "" {
+		if report.Archive == nil {
+			report.Archive = &api.Archive{}
+		}
+		report.Archive.Mode = flags.archive
+	}
+	if report.Archive != nil {
+		if flags.archiveFolder != "" {
+			report.Archive.Folder = flags.archiveFolder
+		}
+		if flags.archiveKeep > 0 {
+			report.Archive.Keep = flags.archiveKeep
+		}
+		if report.Archive.Mode != "tab" && report.Archive.Mode != "copy" {
+			return nil, fmt.Errorf("unknown archive mode: %s", report.Archive.Mode)
+		}
+	}
+	if flags.emailTemplate != "" || flags.emailAttach != "" {
+		if report.Email == nil {
+			report.Email = &api.EmailOptions{}
+		}
+	}
+	if flags.emailTemplate != "" {
+		report.Email.Template = flags.emailTemplate
+	}
+	if flags.emailAttach != "" {
+		report.Email.Attach = flags.emailAttach
+	}
+	if flags.diffKey != "" {
+		if report.Diff == nil {
+			report.Diff = &api.Diff{}
+		}
+		report.Diff.KeyColumn = flags.diffKey
+	}
+
+	if flags.sqlQuery != "" {
+		if report.Sql == nil {
+			report.Sql = &api.SqlQuery{}
+		}
+		report.Sql.Query = flags.sqlQuery
+	}
+	if report.Sql != nil {
+		if flags.sqlDriver != "" {
+			report.Sql.Driver = flags.sqlDriver
+		}
+		if flags.sqlDsn != "" {
+			report.Sql.Dsn = flags.sqlDsn
+			report.Sql.DsnEnv = ""
+		}
+		if report.Sql.Query == "" {
+			return nil, errors.New("sql has no query")
+		}
+	}
+
+	switch flags.style {
+	case "report":
+		if report.HeaderStyle == nil {
+			report.HeaderStyle = api.ReportHeaderStyle()
+		}
+	case "none":
+	default:
+		return nil, fmt.Errorf("unknown style: %s", flags.style)
+	}
+	return report, nil
+}
+
+// addValidationColumns appends an empty column for each validation which adds a column missing from the table
+func addValidationColumns(table [][]string, validations []*api.Validation) [][]string {
+	table[0] = validationHeader(table[0], validations)
+	for i := 1; i < len(table); i++ {
+		for len(table[i]) < len(table[0]) {
+			table[i] = append(table[i], "")
+		}
+	}
+	return table
+}
+
+// validationHeader returns the header with the columns added by validations appended
+func validationHeader(header []string, validations []*api.Validation) []string {
+	for _, validation := range validations {
+		if !validation.AddColumn || validation.Column == "" || sliceIndex(header, validation.Column) >= 0 {
+			continue
+		}
+		header = append(header, validation.Column)
+	}
+	return header
+}
+
+// checkStreamable returns an error for the first option which needs the whole table in memory
+func checkStreamable(report *api.Report, highlightColumns string) error {
+	switch {
+	case len(report.Transforms) > 0:
+		return errors.New("transforms need the whole table")
+	case len(report.Tabs) > 0:
+		return errors.New("tabs need the whole table")
+	case len(report.FormulaColumns) > 0:
+		return errors.New("formula columns need the whole table")
+	case len(report.RowHighlights) > 0:
+		return errors.New("row highlights need the whole table")
+	case highlightColumns != "":
+		return errors.New("highlight columns need the whole table")
+	case report.Diff != nil:
+		return errors.New("diff needs the whole table")
+	}
+	return nil
+}
+
+// archivePrevious keeps the previous report of the spreadsheet, then prunes the oldest archives
+func archivePrevious(logger *api.Logger, service *api.Service, spreadsheetId string, archive *api.Archive) error {
+	now := time.Now()
+	if archive.Mode == "tab" {
+		title, err := service.ArchiveTab(spreadsheetId, now)
+		if err != nil {
+			return err
+		}
+		if title == "" {
+			logger.Warn("spreadsheet has no Sheet1, skipping the archive", "spreadsheet", spreadsheetId)
+		}
+		if archive.Keep > 0 {
+			return service.PruneArchiveTabs(spreadsheetId, archive.Keep)
+		}
+		return nil
+	}
+
+	_, err := service.ArchiveCopy(spreadsheetId, archive.Folder, now)
+	if err != nil {
+		return err
+	}
+	if archive.Keep > 0 {
+		return service.PruneArchiveCopies(spreadsheetId, archive.Keep)
+	}
+	return nil
+}
+
+// transformTabs returns the table of each tab, transformed from the uploaded table before its own transforms
+func transformTabs(table [][]string, tabs []*api.Tab) ([][][]string, error) {
+	var tabTables [][][]string
+	for _, tab := range tabs {
+		tabTable, err := api.ApplyTransforms(table, tab.Transforms)
+		if err != nil {
+			return nil, fmt.Errorf("tab %s: %w", tab.Title, err)
+		}
+		tabTables = append(tabTables, tabTable)
+	}
+	return tabTables, nil
+}
+
+func insertTabs(service *api.Service, spreadsheetId string, tabs []*api.Tab, tabTables [][][]string, textColumns []string) error {
+	for i, tab := range tabs {
+		err := service.InsertTableInSheet(spreadsheetId, tab.Title, api.Origin(), api.QuoteText(tabTables[i], textColumns))
+		if err != nil {
+			return fmt.Errorf("failed to insert tab %s: %w", tab.Title, err)
+		}
+	}
+	return nil
+}
+
+// addSummaryRow writes the summary row of formulas in bold below the table of height rows, including the header
+func addSummaryRow(service *api.Service, spreadsheetId string, header []string, height int, summary *api.Summary) error {
+	row, err := api.SummaryRow(header, height, api.Origin(), summary)
+	if err != nil {
+		return err
+	}
+
+	position := api.Origin().Offset(height, 0)
+	err = service.InsertTable(spreadsheetId, position, [][]string{row})
+	if err != nil {
+		return err
+	}
+
+	bold := true
+	return service.SetStyle(spreadsheetId, position, position.Offset(0, len(row)-1), &api.Style{
+		Bold: &bold,
+		Borders: &api.Borders{
+			Top: &api.Border{
+				Style: "SOLID_MEDIUM",
+			},
+		},
+	})
+}
+
+// highlightRows colors the rows of the table inserted at the origin of the first sheet matching each row highlight
+func highlightRows(service *api.Service, spreadsheetId string, table [][]string, rowHighlights []*api.RowHighlight) error {
+	for _, rowHighlight := range rowHighlights {
+		color := rowHighlight.Color
+		if color == nil {
+			color = defaultRowHighlightColor
+		}
+		err := service.HighlightRows(spreadsheetId, api.Origin(), table, &rowHighlight.Predicate, color)
+		if err != nil {
+			return fmt.Errorf("failed to highlight rows where %s %s %s: %w", rowHighlight.Column, rowHighlight.Operator, rowHighlight.Value, err)
+		}
+	}
+	return nil
+}
+
+// applyReport formats the table of height rows, including the header, inserted at the origin of the first sheet
+func applyReport(service *api.Service, spreadsheetId string, header []string, height int, report *api.Report) error {
+	width := len(header)
+	end := api.Origin().Offset(height-1, width-1)
+
+	if report.Banding != nil {
+		err := service.AddBanding(spreadsheetId, api.Origin(), end, report.Banding)
+		if err != nil {
+			return fmt.Errorf("failed to add banding: %w", err)
+		}
+	}
+
+	if report.HeaderStyle != nil {
+		err := service.SetStyle(spreadsheetId, api.Origin(), api.Origin().Offset(0, width-1), report.HeaderStyle)
+		if err != nil {
+			return fmt.Errorf("failed to style header: %w", err)
+		}
+	}
+
+	for _, rangeStyle := range report.Styles {
+		start, end, err := api.ParseRange(rangeStyle.Range)
+		if err != nil {
+			return err
+		}
+		err = service.SetStyle(spreadsheetId, start, end, &rangeStyle.Style)
+		if err != nil {
+			return fmt.Errorf("failed to style %s: %w", rangeStyle.Range, err)
+		}
+	}
+
+	if report.FreezeRows > 0 || report.FreezeColumns > 0 {
+		err := service.FreezePanes(spreadsheetId, report.FreezeRows, report.FreezeColumns)
+		if err != nil {
+			return fmt.Errorf("failed to freeze panes: %w", err)
+		}
+	}
+
+	if report.AutoResize {
+		err := service.AutoResizeColumns(spreadsheetId, api.Origin().ColumnIndex, end.ColumnIndex)
+		if err != nil {
+			return fmt.Errorf("failed to resize columns: %w", err)
+		}
+	}
+
+	columnWidths := make(map[int]int64)
+	for column, columnWidth := range report.ColumnWidths {
+		index := sliceIndex(header, column)
+		if index < 0 {
+			return missingColumnError(column)
+		}
+		columnWidths[api.Origin().ColumnIndex+index] = columnWidth
+	}
+	err := service.SetColumnWidths(spreadsheetId, columnWidths)
+	if err != nil {
+		return fmt.Errorf("failed to set column widths: %w", err)
+	}
+
+	if report.BasicFilter {
+		err = service.SetBasicFilter(spreadsheetId, api.Origin(), end)
+		if err != nil {
+			return fmt.Errorf("failed to add filter: %w", err)
+		}
+	}
+
+	for _, filterView := range report.FilterViews {
+		err = service.AddFilterView(spreadsheetId, api.Origin(), end, header, filterView)
+		if err != nil {
+			return fmt.Errorf("failed to add filter view %s: %w", filterView.Title, err)
+		}
+	}
+
+	for _, validation := range report.Validations {
+		var start, end *api.CellPosition
+		if validation.Range != "" {
+			start, end, err = api.ParseRange(validation.Range)
+			if err != nil {
+				return err
+			}
+		} else {
+			index := sliceIndex(header, validation.Column)
+			if index < 0 {
+				return missingColumnError(validation.Column)
+			}
+			if height <= 1 {
+				continue
+			}
+			start = api.Origin().Offset(1, index)
+			end = api.Origin().Offset(height-1, index)
+		}
+		err = service.SetDataValidation(spreadsheetId, start, end, &validation.ValidationRule)
+		if err != nil {
+			return fmt.Errorf("failed to add %s validation: %w", validation.Type, err)
+		}
+	}
+
+	for _, pivotTable := range report.PivotTables {
+		err = service.AddPivotTable(spreadsheetId, api.Origin(), end, header, pivotTable)
+		if err != nil {
+			return fmt.Errorf("failed to add pivot table: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyDiff highlights the cells of the table changed since the previous table and lists added and removed rows on a tab
+func applyDiff(service *api.Service, spreadsheetId string, previous [][]string, table [][]string, diff *api.Diff, textColumns []string) error {
+	tableDiff, err := api.DiffTables(previous, table, diff.KeyColumn)
+	if err != nil {
+		return err
+	}
+
+	color := diff.Color
+	if color == nil {
+		color = api.DefaultDiffColor()
+	}
+	err = service.AnnotateChanges(spreadsheetId, api.Origin(), tableDiff.Changes, color)
+	if err != nil {
+		return fmt.Errorf("failed to annotate changes: %w", err)
+	}
+
+	changesTab := diff.ChangesTab
+	if changesTab == "" {
+		changesTab = "Changes"
+	}
+	err = service.InsertTableInSheet(spreadsheetId, changesTab, api.Origin(), api.QuoteText(tableDiff.ChangesTable(table[0]), textColumns))
+	if err != nil {
+		return fmt.Errorf("failed to insert tab %s: %w", changesTab, err)
+	}
+	return nil
+}
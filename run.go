@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/yuhongherald/google-sheet-go/api"
+	"os"
+	"time"
+)
+
+// stepSummary is the duration and the requests of a step of the run
+type stepSummary struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+	api.Stats
+	Error string `json:"error,omitempty"`
+}
+
+// runSummary is written to --summary-file when the run ends, successfully or not
+type runSummary struct {
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	SpreadsheetId string    `json:"spreadsheet_id,omitempty"`
+	Url           string    `json:"url,omitempty"`
+	Started       time.Time `json:"started"`
+	DurationMs    int64     `json:"duration_ms"`
+	api.Stats
+	Steps []*stepSummary `json:"steps"`
+}
+
+// run times the steps of the CLI, counting the requests of the service in each
+type run struct {
+	logger      *api.Logger
+	service     *api.Service
+	summaryFile string
+	summary     runSummary
+
+	current   *stepSummary
+	stepStart time.Time
+	stepStats api.Stats
+}
+
+func newRun(logger *api.Logger, summaryFile string) *run {
+	return &run{
+		logger:      logger,
+		summaryFile: summaryFile,
+		summary: runSummary{
+			Started: time.Now(),
+		},
+	}
+}
+
+func (r *run) stats() api.Stats {
+	if r.service == nil {
+		return api.Stats{}
+	}
+	return r.service.Stats()
+}
+
+// step ends the current step, then starts the step with the given name
+func (r *run) step(name string) {
+	r.endStep(nil)
+	r.logger.Debug("step started", "step", name)
+	r.current = &stepSummary{
+		Name: name,
+	}
+	r.stepStart = time.Now()
+	r.stepStats = r.stats()
+}
+
+// endStep records the duration and requests of the current step, which failed with err if it is not nil
+func (r *run) endStep(err error) {
+	if r.current == nil {
+		return
+	}
+	r.current.DurationMs = time.Since(r.stepStart).Milliseconds()
+	r.current.Stats = r.stats().Sub(r.stepStats)
+	fields := []interface{}{"step", r.current.Name, "duration_ms", r.current.DurationMs,
+		"requests", r.current.Requests, "bytes_sent", r.current.BytesSent, "retries", r.current.Retries}
+	if err != nil {
+		r.current.Error = err.Error()
+		r.logger.Error("step failed", append(fields, "error", err)...)
+	} else {
+		r.logger.Info("step done", fields...)
+	}
+	r.summary.Steps = append(r.summary.Steps, r.current)
+	r.current = nil
+}
+
+// setSpreadsheet records the spreadsheet of the run
+func (r *run) setSpreadsheet(spreadsheetId string) {
+	r.summary.SpreadsheetId = spreadsheetId
+	r.summary.Url = "https://docs.google.com/spreadsheets/d/" + spreadsheetId
+}
+
+// finish ends the current step, logs the totals of the run, which failed with err if it is not nil, and writes the summary file
+func (r *run) finish(err error) {
+	r.endStep(err)
+	r.summary.DurationMs = time.Since(r.summary.Started).Milliseconds()
+	r.summary.Stats = r.stats()
+	fields := []interface{}{"duration_ms", r.summary.DurationMs,
+		"requests", r.summary.Requests, "bytes_sent", r.summary.BytesSent, "retries", r.summary.Retries}
+	r.summary.Status = "ok"
+	if err != nil {
+		r.summary.Status = "failed"
+		r.summary.Error = err.Error()
+		r.logger.Error("run failed", append(fields, "error", err)...)
+	} else {
+		r.logger.Info("run done", fields...)
+	}
+
+	if r.summaryFile == "" {
+		return
+	}
+	data, marshalErr := json.MarshalIndent(r.summary, "", "  ")
+	if marshalErr == nil {
+		marshalErr = os.WriteFile(r.summaryFile, append(data, '\n'), 0644)
+	}
+	if marshalErr != nil {
+		r.logger.Error("failed to write summary file", "file", r.summaryFile, "error", marshalErr)
+	}
+}
+
+// fatalf fails the current step and the run with the formatted error, then exits
+func (r *run) fatalf(format string, a ...interface{}) {
+	r.finish(fmt.Errorf(format, a...))
+	os.Exit(1)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"github.com/yuhongherald/google-sheet-go/api"
+	"strings"
+)
+
+// parsePermissions parses the comma separated [type:]target[:role] entries of --users
+func parsePermissions(entries string) ([]*api.Permission, error) {
+	var permissions []*api.Permission
+	if entries == "" {
+		return permissions, nil
+	}
+	for _, entry := range strings.Split(entries, ",") {
+		permission, err := api.ParsePermission(entry)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+// recipients returns the emails of the users and groups given permissions, comma separated
+func recipients(permissions []*api.Permission) string {
+	var emails []string
+	for _, permission := range permissions {
+		if permission.EmailAddress != "" {
+			emails = append(emails, permission.EmailAddress)
+		}
+	}
+	return strings.Join(emails, ",")
+}
+
+// planPermissionSync logs and returns the changes making the permissions of the file the desired permissions.
+// A file which does not exist yet, with an empty id, has no permissions.
+func planPermissionSync(logger *api.Logger, service *api.Service, fileId string, desired []*api.Permission) ([]*api.PermissionChange, error) {
+	var current []*api.Permission
+	self := ""
+	if fileId != "" {
+		var err error
+		current, err = service.ListPermissions(fileId)
+		if err != nil {
+			return nil, err
+		}
+		self, err = service.AccountEmail()
+		if err != nil {
+			return nil, err
+		}
+	}
+	plan := api.PlanPermissions(current, desired, self)
+	if len(plan) == 0 {
+		logger.Info("permissions are up to date")
+		return nil, nil
+	}
+	logger.Info("permission plan", "changes", len(plan))
+	for _, change := range plan {
+		logger.Info("planned permission change", "change", change)
+	}
+	return plan, nil
+}
+
+// applyPermissionSync makes the permissions of the file exactly the desired permissions, logging the plan first.
+// The permission of the account running the sync is kept, so that it can still write the spreadsheet.
+func applyPermissionSync(logger *api.Logger, service *api.Service, fileId string, desired []*api.Permission, options *api.ShareOptions) error {
+	plan, err := planPermissionSync(logger, service, fileId, desired)
+	if err != nil || len(plan) == 0 {
+		return err
+	}
+	return service.ApplyPermissionPlan(fileId, plan, options)
+}
+
+// revokePermissions removes the permissions of the file with the same type and email or domain as each of revoked
+func revokePermissions(service *api.Service, fileId string, revoked []*api.Permission) error {
+	if len(revoked) == 0 {
+		return nil
+	}
+	permissions, err := service.ListPermissions(fileId)
+	if err != nil {
+		return err
+	}
+	for _, revoke := range revoked {
+		for _, permission := range permissions {
+			if permission.Type != revoke.Type ||
+				!strings.EqualFold(permission.EmailAddress, revoke.EmailAddress) ||
+				!strings.EqualFold(permission.Domain, revoke.Domain) {
+				continue
+			}
+			err = service.Revoke(fileId, permission.Id)
+			if err != nil {
+				return fmt.Errorf("failed to revoke %s permission: %w", permission.Role, err)
+			}
+		}
+	}
+	return nil
+}